@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+
+	"backend/logic"
+	"backend/models"
+)
+
+// QuizAnalytics summarizes the scores of graded responses. Responses stored
+// before the form became a quiz, or shown no question worth points, carry no
+// score and are not counted.
+type QuizAnalytics struct {
+	GradedN        int                 `json:"gradedN"`
+	PassPercent    float64             `json:"passPercent"`
+	AveragePercent *float64            `json:"averagePercent,omitempty"`
+	PassRate       *float64            `json:"passRate,omitempty"` // percent of graded responses that passed
+	Distribution   []Bar               `json:"distribution"`       // scores in 10-point percent bands
+	Questions      []QuestionAnalytics `json:"questions"`
+}
+
+// QuestionAnalytics is how respondents did on one gradable question.
+// PercentCorrect counts full credit only; partial credit shows in
+// AveragePoints.
+type QuestionAnalytics struct {
+	FieldID        string   `json:"fieldId"`
+	Label          string   `json:"label"`
+	MaxPoints      float64  `json:"maxPoints"`
+	GradedN        int      `json:"gradedN"`
+	PercentCorrect *float64 `json:"percentCorrect,omitempty"`
+	AveragePoints  *float64 `json:"averagePoints,omitempty"`
+}
+
+func computeQuiz(form models.Form, responses []models.Response) *QuizAnalytics {
+	qa := &QuizAnalytics{PassPercent: form.Quiz.PassPercent, Questions: []QuestionAnalytics{}}
+
+	bands := make([]int, 10)
+	var percentSum float64
+	passed := 0
+	type tally struct {
+		n, correct int
+		points     float64
+	}
+	byField := map[string]*tally{}
+	for _, r := range responses {
+		if r.Score == nil {
+			continue
+		}
+		qa.GradedN++
+		percentSum += r.Score.Percent
+		if r.Score.Passed {
+			passed++
+		}
+		band := int(math.Min(r.Score.Percent/10, 9)) // 100% joins the 90-100 band
+		if band < 0 {
+			band = 0
+		}
+		bands[band]++
+		for _, q := range r.Score.Questions {
+			t := byField[q.FieldID]
+			if t == nil {
+				t = &tally{}
+				byField[q.FieldID] = t
+			}
+			t.n++
+			t.points += q.Points
+			if q.Correct {
+				t.correct++
+			}
+		}
+	}
+
+	for i, n := range bands {
+		label := fmt.Sprintf("%d-%d%%", i*10, i*10+9)
+		if i == 9 {
+			label = "90-100%"
+		}
+		qa.Distribution = append(qa.Distribution, Bar{Label: label, Value: n})
+	}
+	if qa.GradedN > 0 {
+		avg := percentSum / float64(qa.GradedN)
+		rate := float64(passed) / float64(qa.GradedN) * 100
+		qa.AveragePercent, qa.PassRate = &avg, &rate
+	}
+
+	for _, f := range form.Fields {
+		if !logic.Gradable(f) {
+			continue
+		}
+		q := QuestionAnalytics{FieldID: f.ID, Label: f.Label, MaxPoints: logic.QuestionPoints(f)}
+		if t := byField[f.ID]; t != nil {
+			pct := float64(t.correct) / float64(t.n) * 100
+			avg := t.points / float64(t.n)
+			q.GradedN, q.PercentCorrect, q.AveragePoints = t.n, &pct, &avg
+		}
+		qa.Questions = append(qa.Questions, q)
+	}
+	return qa
+}
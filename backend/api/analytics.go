@@ -35,17 +35,16 @@ func LongPollAnalytics(c *fiber.Ctx) error {
 	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "form not found")
 	}
+	if form.ArchivedAt != nil {
+		return fiber.NewError(fiber.StatusGone, "form has been deleted")
+	}
 	current := int64(0)
 	if form.LastResponseAt != nil {
 		current = form.LastResponseAt.UnixMilli()
 	}
 	if current > sinceMs {
 		// Compute and return
-		cur, _ := db.Responses().Find(c.Context(), bson.M{"formId": id})
-		var resps []models.Response
-		_ = cur.All(c.Context(), &resps)
-		an := analytics.Compute(form, resps)
-		return c.JSON(an)
+		return liveAnalytics(c, form)
 	}
 
 	// Wait for up to 25s (or until request canceled)
@@ -56,13 +55,27 @@ func LongPollAnalytics(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{"timeout": true, "lastResponseMs": current})
 	}
 
-	// Something changed, reload and reply
-	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	// Something changed, reload and reply. Archiving or deleting the form also
+	// wakes waiters, which then end with 410 instead of waiting out the timeout.
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil || form.ArchivedAt != nil {
+		return fiber.NewError(fiber.StatusGone, "form has been deleted")
+	}
+	return liveAnalytics(c, form)
+}
+
+// liveAnalytics computes merged-version analytics for an already loaded form.
+func liveAnalytics(c *fiber.Ctx, form models.Form) error {
+	cur, err := db.Responses().Find(c.Context(), bson.M{"formId": form.ID})
+	if err != nil {
+		return err
 	}
-	cur, _ := db.Responses().Find(c.Context(), bson.M{"formId": id})
 	var resps []models.Response
-	_ = cur.All(c.Context(), &resps)
-	an := analytics.Compute(form, resps)
-	return c.JSON(an)
+	if err := cur.All(c.Context(), &resps); err != nil {
+		return err
+	}
+	resps, err = mergeVersions(c.Context(), form, resps)
+	if err != nil {
+		return err
+	}
+	return c.JSON(analytics.Compute(form, resps))
 }
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"backend/db"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Responses are removed in batches so deleting a large form never holds one
+// huge DeleteMany open.
+const deleteBatchSize = 500
+
+// DELETE /api/forms/:id            archive (soft delete, restorable)
+// DELETE /api/forms/:id?hard=true  permanently delete the form and its responses
+func DeleteForm(c *fiber.Ctx) error {
+	if c.QueryBool("hard") {
+		return hardDeleteForm(c)
+	}
+	id := c.Params("id")
+	now := time.Now().UTC()
+	filter := bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"archivedAt": now, "updatedAt": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var form models.Form
+	err := db.Forms().FindOneAndUpdate(c.Context(), filter, update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if n, _ := db.Forms().CountDocuments(c.Context(), bson.M{"_id": id}); n > 0 {
+			return fiber.NewError(fiber.StatusConflict, "form already archived")
+		}
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	// End open analytics long-polls; they answer 410 once woken
+	hub.Notify(id)
+	return c.JSON(form)
+}
+
+// POST /api/forms/:id/restore
+func RestoreForm(c *fiber.Ctx) error {
+	id := c.Params("id")
+	filter := bson.M{"_id": id, "archivedAt": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"archivedAt": ""},
+		"$set":   bson.M{"updatedAt": time.Now().UTC()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var form models.Form
+	err := db.Forms().FindOneAndUpdate(c.Context(), filter, update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if n, _ := db.Forms().CountDocuments(c.Context(), bson.M{"_id": id}); n > 0 {
+			return fiber.NewError(fiber.StatusConflict, "form is not archived")
+		}
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(form)
+}
+
+// hardDeleteForm archives the form first so it stops accepting responses,
+// then purges it (see formPurge.run). A failure before the form document is
+// removed leaves an archived form that can be deleted again.
+func hardDeleteForm(c *fiber.Ctx) error {
+	id := c.Params("id")
+	now := time.Now().UTC()
+	res, err := db.Forms().UpdateOne(c.Context(), bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"archivedAt": now, "updatedAt": now}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if n, _ := db.Forms().CountDocuments(c.Context(), bson.M{"_id": id}); n == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+	}
+	hub.Notify(id)
+
+	deleted, err := mongoPurge.run(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"deleted": true, "responsesDeleted": deleted})
+}
+
+// formPurge holds the steps that remove an archived form and everything
+// stored for it; they are functions so the order can be tested without a
+// database.
+type formPurge struct {
+	responses func(ctx context.Context, formID string) (int64, error)
+	versions  func(ctx context.Context, formID string) error
+	uploads   func(ctx context.Context, formID string) error
+	form      func(ctx context.Context, formID string) error
+}
+
+var mongoPurge = formPurge{
+	responses: deleteResponses,
+	versions: func(ctx context.Context, formID string) error {
+		_, err := db.FormVersions().DeleteMany(ctx, bson.M{"formId": formID})
+		return err
+	},
+	uploads: deleteUploads,
+	form: func(ctx context.Context, formID string) error {
+		_, err := db.Forms().DeleteOne(ctx, bson.M{"_id": formID})
+		return err
+	},
+}
+
+// run removes responses, versions and uploads, then the form, and returns
+// how many responses it deleted. A submission that reserved its slot before
+// the form was archived can still store its response and claim its uploads
+// after the first sweep, so responses and uploads are swept again once the
+// form is gone and no new submission can start.
+func (p formPurge) run(ctx context.Context, id string) (int64, error) {
+	deleted, err := p.responses(ctx, id)
+	if err != nil {
+		return deleted, err
+	}
+	if err := p.versions(ctx, id); err != nil {
+		return deleted, err
+	}
+	if err := p.uploads(ctx, id); err != nil {
+		return deleted, err
+	}
+	if err := p.form(ctx, id); err != nil {
+		return deleted, err
+	}
+	late, err := p.responses(ctx, id)
+	deleted += late
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, p.uploads(ctx, id)
+}
+
+func deleteResponses(ctx context.Context, formID string) (int64, error) {
+	var total int64
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(deleteBatchSize)
+	for {
+		cur, err := db.Responses().Find(ctx, bson.M{"formId": formID}, opts)
+		if err != nil {
+			return total, err
+		}
+		var batch []struct {
+			ID string `bson:"_id"`
+		}
+		if err := cur.All(ctx, &batch); err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+		ids := make(bson.A, len(batch))
+		for i, r := range batch {
+			ids[i] = r.ID
+		}
+		res, err := db.Responses().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return total, err
+		}
+		total += res.DeletedCount
+	}
+}
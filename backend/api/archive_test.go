@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// A submission that reserved its slot before the form was archived stores
+// its response and claims an upload while the purge runs; nothing may be
+// left behind once the form is gone.
+func TestFormPurgeSweepsLateSubmissions(t *testing.T) {
+	responses := map[string]bool{"r1": true, "r2": true}
+	uploads := map[string]bool{"u1": true}
+	formExists := true
+	var steps []string
+
+	late := func() {
+		responses["late"] = true
+		uploads["late-upload"] = true
+	}
+	p := formPurge{
+		responses: func(context.Context, string) (int64, error) {
+			steps = append(steps, "responses")
+			n := int64(len(responses))
+			responses = map[string]bool{}
+			return n, nil
+		},
+		versions: func(context.Context, string) error {
+			steps = append(steps, "versions")
+			late() // lands after the first sweep
+			return nil
+		},
+		uploads: func(context.Context, string) error {
+			steps = append(steps, "uploads")
+			uploads = map[string]bool{}
+			return nil
+		},
+		form: func(context.Context, string) error {
+			steps = append(steps, "form")
+			formExists = false
+			return nil
+		},
+	}
+
+	deleted, err := p.run(context.Background(), "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Errorf("deleted %d responses, want 3", deleted)
+	}
+	if formExists || len(responses) > 0 || len(uploads) > 0 {
+		t.Errorf("left behind: form %v, responses %v, uploads %v", formExists, responses, uploads)
+	}
+	want := []string{"responses", "versions", "uploads", "form", "responses", "uploads"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps %v, want %v", steps, want)
+	}
+}
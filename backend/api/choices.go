@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseChoicesCSV reads "value[,label]" rows. A first row of exactly
+// "value,label" (or "value") is treated as a header.
+func parseChoicesCSV(body []byte) ([]models.Choice, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var out []models.Choice
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), "value") &&
+			(len(rec) == 1 || strings.EqualFold(strings.TrimSpace(rec[1]), "label")) {
+			continue
+		}
+		if len(rec) > 2 {
+			return nil, fmt.Errorf("line %d: expected value[,label]", line)
+		}
+		c := models.Choice{Value: strings.TrimSpace(rec[0])}
+		if len(rec) == 2 {
+			c.Label = strings.TrimSpace(rec[1])
+		}
+		if c.Value == "" && c.Label == "" {
+			continue // blank line
+		}
+		out = append(out, c)
+		if len(out) > fieldtypes.MaxChoices {
+			return nil, fmt.Errorf("at most %d choices", fieldtypes.MaxChoices)
+		}
+	}
+}
+
+// POST /api/forms/:id/fields/:fieldId/choices/import?mode=replace|append
+// Body: CSV of value[,label] rows. Requires If-Match like PUT.
+func ImportChoices(c *fiber.Ctx) error {
+	id := c.Params("id")
+	rev, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	mode := c.Query("mode", "replace")
+	if mode != "replace" && mode != "append" {
+		return fiber.NewError(fiber.StatusBadRequest, "mode must be replace or append")
+	}
+	choices, err := parseChoicesCSV(c.Body())
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid CSV: "+err.Error())
+	}
+
+	var current models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&current); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if rev != anyRevision && rev != current.Revision {
+		return staleWrite(c, id)
+	}
+	at := indexOfField(current.Fields, c.Params("fieldId"))
+	if at < 0 {
+		return fiber.NewError(fiber.StatusNotFound, "field not found")
+	}
+	if current.Fields[at].Type != "dropdown" {
+		return fiber.NewError(fiber.StatusBadRequest, "choices can only be imported into dropdown fields")
+	}
+
+	fields := append([]models.Field(nil), current.Fields...)
+	f := fields[at]
+	if mode == "append" {
+		choices = append(append([]models.Choice(nil), f.Choices...), choices...)
+	}
+	f.Choices = choices
+	fields[at] = f
+
+	if errs := validateFields(fields, func(i int) bool { return i == at }); len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+	form, err := saveDefinition(c, current, formPayload{Title: current.Title, Fields: fields, Pages: current.Pages, Quiz: current.Quiz})
+	if err != nil {
+		return err
+	}
+	setETag(c, form)
+	return c.JSON(form)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+// definitionSchemaVersion is bumped whenever the definition document changes
+// shape in a way older importers cannot read.
+const definitionSchemaVersion = 1
+
+const definitionKind = "form"
+
+// formDefinition is the portable, environment-independent description of a
+// form used to move it between deployments. It carries no ids of the source
+// database other than for reference, and no responses or lifecycle state.
+type formDefinition struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Kind          string             `json:"kind"`
+	ExportedAt    *time.Time         `json:"exportedAt,omitempty"`
+	Source        *definitionSource  `json:"source,omitempty"`
+	Title         string             `json:"title"`
+	Fields        []models.Field     `json:"fields"`
+	Pages         []models.Page      `json:"pages,omitempty"`
+	Settings      definitionSettings `json:"settings"`
+}
+
+type definitionSource struct {
+	FormID  string `json:"formId"`
+	Version int    `json:"version,omitempty"`
+}
+
+// definitionSettings holds form-level options that travel with the form:
+// quiz grading, and the schedule and response cap.
+type definitionSettings struct {
+	Quiz *models.Quiz `json:"quiz,omitempty"`
+	schedulePayload
+}
+
+func definitionFromForm(form models.Form, version int) formDefinition {
+	now := time.Now().UTC()
+	return formDefinition{
+		SchemaVersion: definitionSchemaVersion,
+		Kind:          definitionKind,
+		ExportedAt:    &now,
+		Source:        &definitionSource{FormID: form.ID, Version: version},
+		Title:         form.Title,
+		Fields:        form.Fields,
+		Pages:         form.Pages,
+		Settings:      definitionSettings{Quiz: form.Quiz, schedulePayload: scheduleOf(form)},
+	}
+}
+
+// toYAML renders v as block-style YAML keeping the JSON property names and
+// order: the JSON encoding is parsed as YAML (a superset of JSON) and the
+// flow styles it produces are cleared.
+func toYAML(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	var unstyle func(n *yaml.Node)
+	unstyle = func(n *yaml.Node) {
+		// YAML 1.1 readers take these as booleans, so keep them quoted.
+		switch strings.ToLower(n.Value) {
+		case "y", "yes", "n", "no", "on", "off":
+			if n.Kind == yaml.ScalarNode && n.Tag == "!!str" {
+				return
+			}
+		}
+		n.Style = 0
+		for _, c := range n.Content {
+			unstyle(c)
+		}
+	}
+	unstyle(&node)
+	return yaml.Marshal(&node)
+}
+
+func wantsYAML(c *fiber.Ctx) bool {
+	switch c.Query("format") {
+	case "yaml", "yml":
+		return true
+	case "json":
+		return false
+	}
+	ct := c.Get(fiber.HeaderContentType)
+	if c.Method() == fiber.MethodGet {
+		ct = c.Get(fiber.HeaderAccept)
+	}
+	return strings.Contains(ct, "yaml")
+}
+
+// GET /api/forms/:id/definition?format=json|yaml&version=N
+func ExportDefinition(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	version := 0
+	if v := c.Query("version"); v != "" {
+		snap, err := findVersion(c, id, v)
+		if err != nil {
+			return err
+		}
+		form.Title, form.Fields, form.Pages, form.Quiz, version = snap.Title, snap.Fields, snap.Pages, snap.Quiz, snap.Version
+	}
+	def := definitionFromForm(form, version)
+
+	if wantsYAML(c) {
+		out, err := toYAML(def)
+		if err != nil {
+			return err
+		}
+		c.Attachment(fmt.Sprintf("%s.form.yaml", safeName(form.Title)))
+		c.Set(fiber.HeaderContentType, "application/yaml")
+		return c.Send(out)
+	}
+	c.Attachment(fmt.Sprintf("%s.form.json", safeName(form.Title)))
+	return c.JSON(def)
+}
+
+// POST /api/forms/import   (JSON, or YAML with Content-Type application/yaml or ?format=yaml)
+// Creates a new draft form. Every problem in the document is reported.
+func ImportDefinition(c *fiber.Ctx) error {
+	raw := c.Body()
+	if wantsYAML(c) {
+		var doc interface{}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid YAML")
+		}
+		var err error
+		if raw, err = json.Marshal(doc); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid YAML")
+		}
+	}
+	var def formDefinition
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid definition: "+err.Error())
+	}
+
+	var errs formErrors
+	if def.Kind != definitionKind {
+		errs = append(errs, validationError{Path: "kind", Code: fieldtypes.CodeUnknownType, Message: fmt.Sprintf("kind must be %q", definitionKind)})
+	}
+	if def.SchemaVersion < 1 || def.SchemaVersion > definitionSchemaVersion {
+		errs = append(errs, validationError{Path: "schemaVersion", Code: fieldtypes.CodeOutOfRange, Message: fmt.Sprintf("unsupported schemaVersion %d", def.SchemaVersion)})
+	}
+	p := formPayload{Title: def.Title, Fields: def.Fields, Pages: def.Pages, Quiz: def.Settings.Quiz}
+	errs = append(errs, validateFormPayload(p)...)
+	errs = append(errs, def.Settings.validate("settings.")...)
+	if len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+	return insertForm(c, p, def.Settings.schedulePayload)
+}
@@ -2,107 +2,293 @@ package api
 
 import (
 	"backend/db"
+	"backend/fieldtypes"
+	"backend/logic"
 	"backend/models"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type formPayload struct {
 	Title  string         `json:"title"`
 	Fields []models.Field `json:"fields"`
+	Pages  []models.Page  `json:"pages,omitempty"`
+	Quiz   *models.Quiz   `json:"quiz,omitempty"`
+}
+
+// validationError describes one problem in a form definition. Path is rooted
+// at the payload, e.g. "fields[3].maxLength".
+type validationError struct {
+	Path    string `json:"path"`
+	FieldID string `json:"fieldId,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
-func validateField(f models.Field) error {
-	if f.ID == "" || f.Label == "" || f.Type == "" {
-		return errors.New("field must have id, label, and type")
+// formErrors lists every problem found in a form payload.
+type formErrors []validationError
+
+func (e formErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, v := range e {
+		parts[i] = v.Path + ": " + v.Message
 	}
-	switch f.Type {
-	case "text":
-		if f.MinLength != nil && *f.MinLength < 0 {
-			return errors.New("minLength must be >= 0")
+	return strings.Join(parts, "; ")
+}
+
+// invalidForm answers 400 with every validation error.
+func invalidForm(c *fiber.Ctx, errs formErrors) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errs.Error(), "errors": errs})
+}
+
+// validateField checks one field definition. Paths are relative to the field
+// ("maxLength", "options[2]"); validateFormPayload roots them.
+func validateField(f models.Field) formErrors {
+	var errs formErrors
+	add := func(path, code, msg string) {
+		errs = append(errs, validationError{Path: path, FieldID: f.ID, Code: code, Message: msg})
+	}
+
+	if f.ID == "" {
+		add("id", fieldtypes.CodeRequired, "field must have an id")
+	}
+	if f.Label == "" {
+		add("label", fieldtypes.CodeRequired, "field must have a label")
+	}
+	seen := make(map[string]struct{}, len(f.Options))
+	for i, o := range f.Options {
+		if _, dup := seen[o]; dup {
+			add(fmt.Sprintf("options[%d]", i), fieldtypes.CodeDuplicate, fmt.Sprintf("duplicate option %q", o))
 		}
-		if f.MaxLength != nil && *f.MaxLength < 0 {
-			return errors.New("maxLength must be >= 0")
+		seen[o] = struct{}{}
+	}
+
+	if f.Type == "" {
+		add("type", fieldtypes.CodeRequired, "field must have a type")
+		return errs
+	}
+	t, ok := fieldtypes.Lookup(f.Type)
+	if !ok {
+		add("type", fieldtypes.CodeUnknownType, fmt.Sprintf("unknown field type %q", f.Type))
+		return errs
+	}
+	if _, grader := t.(fieldtypes.Grader); !grader &&
+		(len(f.CorrectAnswers) > 0 || f.Points != nil || f.PartialCredit) {
+		add("correctAnswers", fieldtypes.CodeInvalid, fmt.Sprintf("%s questions cannot be graded", f.Type))
+	}
+	for _, p := range t.ValidateDefinition(f) {
+		add(p.Path, p.Code, p.Message)
+	}
+	return errs
+}
+
+// validateFields validates fields[i] for every i where check(i) is true, and
+// checks ids are unique across all of them.
+func validateFields(fields []models.Field, check func(i int) bool) formErrors {
+	var errs formErrors
+	ids := make(map[string]int, len(fields))
+	for i, f := range fields {
+		root := fmt.Sprintf("fields[%d]", i)
+		if first, dup := ids[f.ID]; dup && f.ID != "" {
+			errs = append(errs, validationError{
+				Path: root + ".id", FieldID: f.ID, Code: fieldtypes.CodeDuplicate,
+				Message: fmt.Sprintf("duplicate field id %q (also fields[%d])", f.ID, first),
+			})
+		} else {
+			ids[f.ID] = i
+		}
+		if !check(i) {
+			continue
 		}
-		if f.MinLength != nil && f.MaxLength != nil && *f.MinLength > *f.MaxLength {
-			return errors.New("minLength cannot exceed maxLength")
+		for _, e := range validateField(f) {
+			e.Path = root + "." + e.Path
+			errs = append(errs, e)
 		}
-	case "multipleChoice":
-		if len(f.Options) == 0 {
-			return errors.New("multipleChoice requires options")
+	}
+	errs = append(errs, validateConditions(fields)...)
+	return append(errs, validateExpressions(fields)...)
+}
+
+// validateExpressions parses calculated fields' expressions and checks that
+// they read existing fields and do not depend on each other in a cycle.
+func validateExpressions(fields []models.Field) formErrors {
+	var errs formErrors
+	known := make(map[string]bool, len(fields))
+	at := make(map[string]int, len(fields))
+	for i, f := range fields {
+		known[f.ID] = true
+		at[f.ID] = i
+	}
+	deps := map[string][]string{}
+	for i, f := range fields {
+		src, computed := fieldtypes.ExpressionOf(f)
+		if !computed || src == "" {
+			continue
 		}
-	case "checkboxes":
-		if len(f.Options) == 0 {
-			return errors.New("checkboxes requires options")
+		path := fmt.Sprintf("fields[%d].expression", i)
+		e, err := logic.ParseExpr(src)
+		if err != nil {
+			errs = append(errs, validationError{Path: path, FieldID: f.ID, Code: fieldtypes.CodeInvalid, Message: err.Error()})
+			continue
 		}
-		if f.MinChecked != nil && *f.MinChecked < 0 {
-			return errors.New("minChecked must be >= 0")
+		deps[f.ID] = nil
+		for _, ref := range e.Refs() {
+			if !known[ref] {
+				errs = append(errs, validationError{Path: path, FieldID: f.ID, Code: fieldtypes.CodeUnknownRef,
+					Message: fmt.Sprintf("expression references unknown field %q", ref)})
+				continue
+			}
+			deps[f.ID] = append(deps[f.ID], ref)
 		}
-		if f.MaxChecked != nil && *f.MaxChecked < 0 {
-			return errors.New("maxChecked must be >= 0")
+	}
+	for _, cycle := range logic.Cycles(deps) {
+		for _, id := range cycle {
+			errs = append(errs, validationError{
+				Path: fmt.Sprintf("fields[%d].expression", at[id]), FieldID: id, Code: fieldtypes.CodeCycle,
+				Message: "calculated fields depend on each other: " + strings.Join(cycle, ", "),
+			})
 		}
-		if f.MinChecked != nil && f.MaxChecked != nil && *f.MinChecked > *f.MaxChecked {
-			return errors.New("minChecked cannot exceed maxChecked")
+	}
+	return errs
+}
+
+// validateConditions checks visibility conditions across the whole form:
+// references must name existing fields and must not form a cycle. It always
+// looks at every field, since removing one can break another's condition.
+func validateConditions(fields []models.Field) formErrors {
+	var errs formErrors
+	known := make(map[string]bool, len(fields))
+	at := make(map[string]int, len(fields))
+	for i, f := range fields {
+		known[f.ID] = true
+		at[f.ID] = i
+	}
+	deps := map[string][]string{}
+	for i, f := range fields {
+		if f.VisibleIf == nil {
+			continue
 		}
-	case "rating":
-		scale := 5
-		if f.Scale != nil {
-			scale = *f.Scale
+		root := fmt.Sprintf("fields[%d].visibleIf", i)
+		for _, p := range logic.CheckCondition(*f.VisibleIf, known) {
+			path := root
+			if p.Path != "" {
+				path += "." + p.Path
+			}
+			errs = append(errs, validationError{Path: path, FieldID: f.ID, Code: p.Code, Message: p.Message})
 		}
-		if scale < 1 || scale > 10 {
-			return errors.New("rating scale must be 1..10")
+		for _, ref := range logic.Refs(f.VisibleIf) {
+			if known[ref] {
+				deps[f.ID] = append(deps[f.ID], ref)
+			}
 		}
-		if f.Min != nil && *f.Min < 0 {
-			return errors.New("rating min must be >= 0")
+	}
+	for _, cycle := range logic.Cycles(deps) {
+		for _, id := range cycle {
+			errs = append(errs, validationError{
+				Path: fmt.Sprintf("fields[%d].visibleIf", at[id]), FieldID: id, Code: fieldtypes.CodeCycle,
+				Message: "visibility conditions form a cycle: " + strings.Join(cycle, ", "),
+			})
 		}
-	default:
-		return errors.New("unknown field type")
 	}
-	return nil
+	return errs
 }
 
-func validateFormPayload(p formPayload) error {
+func validateFormPayload(p formPayload) formErrors {
+	var errs formErrors
 	if p.Title == "" {
-		return errors.New("title required")
+		errs = append(errs, validationError{Path: "title", Code: fieldtypes.CodeRequired, Message: "title required"})
 	}
 	if len(p.Fields) == 0 {
-		return errors.New("fields required")
+		errs = append(errs, validationError{Path: "fields", Code: fieldtypes.CodeRequired, Message: "fields required"})
 	}
+	errs = append(errs, validateFields(p.Fields, func(int) bool { return true })...)
+	errs = append(errs, validatePages(p)...)
+	return append(errs, validateQuiz(p)...)
+}
+
+// validatePages checks the page layout and branching against p's fields.
+func validatePages(p formPayload) formErrors {
+	var errs formErrors
+	for _, pr := range logic.CheckPages(p.Fields, p.Pages) {
+		errs = append(errs, validationError{Path: pr.Path, Code: pr.Code, Message: pr.Message})
+	}
+	return errs
+}
+
+// validateQuiz checks the quiz settings: a pass mark in range and at least
+// one question that can be graded.
+func validateQuiz(p formPayload) formErrors {
+	if p.Quiz == nil {
+		return nil
+	}
+	var errs formErrors
+	if p.Quiz.PassPercent < 0 || p.Quiz.PassPercent > 100 {
+		errs = append(errs, validationError{Path: "quiz.passPercent", Code: fieldtypes.CodeOutOfRange, Message: "passPercent must be between 0 and 100"})
+	}
+	gradable := false
 	for _, f := range p.Fields {
-		if err := validateField(f); err != nil {
-			return err
+		if logic.Gradable(f) && logic.QuestionPoints(f) > 0 {
+			gradable = true
 		}
 	}
-	return nil
+	if !gradable {
+		errs = append(errs, validationError{Path: "quiz", Code: fieldtypes.CodeRequired, Message: "a quiz needs at least one question with correctAnswers worth points"})
+	}
+	return errs
 }
 
+// POST /api/forms
+// POST /api/forms?fromTemplate=<templateId>  (body optional: {"title": "..."})
 func CreateForm(c *fiber.Ctx) error {
+	if tid := c.Query("fromTemplate"); tid != "" {
+		return createFromTemplate(c, tid)
+	}
+
 	var p formPayload
 	if err := c.BodyParser(&p); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
 	}
-	if err := validateFormPayload(p); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	if errs := validateFormPayload(p); len(errs) > 0 {
+		return invalidForm(c, errs)
 	}
+	return insertForm(c, p, schedulePayload{})
+}
 
+// insertForm stores a new draft form with schedule sched and answers 201
+// with it.
+func insertForm(c *fiber.Ctx, p formPayload, sched schedulePayload) error {
 	now := time.Now().UTC()
 	id := primitive.NewObjectID().Hex()
 	form := models.Form{
 		ID:            id,
 		Title:         p.Title,
 		Fields:        p.Fields,
+		Pages:         p.Pages,
+		Quiz:          p.Quiz,
+		Status:        models.StatusDraft,
+		Revision:      1,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 		ResponseCount: 0,
 	}
+	sched.apply(&form)
 
 	if _, err := db.Forms().InsertOne(c.Context(), form); err != nil {
 		return err
 	}
+	setETag(c, form)
 	return c.Status(fiber.StatusCreated).JSON(form)
 }
 
@@ -112,29 +298,305 @@ func GetForm(c *fiber.Ctx) error {
 	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "form not found")
 	}
+	form.Status = form.EffectiveStatus()
+	form.Availability = form.AvailabilityAt(time.Now().UTC())
+	setETag(c, form)
+	return c.JSON(form)
+}
+
+// GET /api/forms/:id/respond
+// The form as the respondent page needs it: quiz answer keys and points are
+// left out so taking the form does not reveal them, and availability says
+// whether a response would be accepted now.
+func GetRespondentForm(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if form.ArchivedAt != nil {
+		return fiber.NewError(fiber.StatusGone, "form has been deleted")
+	}
+	form.Status = form.EffectiveStatus()
+	form.Availability = form.AvailabilityAt(time.Now().UTC())
+	form.Fields = respondentFields(form.Fields)
 	return c.JSON(form)
 }
 
+// respondentFields copies fields without their grading properties.
+func respondentFields(fields []models.Field) []models.Field {
+	out := make([]models.Field, len(fields))
+	for i, f := range fields {
+		f.CorrectAnswers, f.Points, f.PartialCredit = nil, nil, false
+		out[i] = f
+	}
+	return out
+}
+
+// answerKeys lists the stored values an answer to f may refer to: options,
+// dropdown choice values, and matrix rows and columns. A matrix also lists
+// its answer shape, since single- and multi-select rows store different
+// types.
+func answerKeys(f models.Field) []string {
+	keys := append([]string(nil), f.Options...)
+	for _, ch := range f.Choices {
+		keys = append(keys, "choice:"+ch.Value)
+	}
+	for _, r := range f.Rows {
+		keys = append(keys, "row:"+r.ID)
+	}
+	for _, col := range f.Columns {
+		keys = append(keys, "column:"+col)
+	}
+	if f.Type == "matrix" {
+		keys = append(keys, "multiSelect:"+strconv.FormatBool(f.MultiSelect))
+	}
+	return keys
+}
+
+// structuralChange reports whether next changes fields in a way that would make
+// existing responses unreadable: a field removed or retyped, an option,
+// choice value, matrix row or column removed or renamed, or a matrix switched
+// between single and multi-select. Label edits,
+// reordering and additions are safe.
+func structuralChange(prev, next []models.Field) bool {
+	byID := make(map[string]models.Field, len(next))
+	for _, f := range next {
+		byID[f.ID] = f
+	}
+	for _, old := range prev {
+		f, ok := byID[old.ID]
+		if !ok || f.Type != old.Type {
+			return true
+		}
+		keys := map[string]struct{}{}
+		for _, k := range answerKeys(f) {
+			keys[k] = struct{}{}
+		}
+		for _, k := range answerKeys(old) {
+			if _, ok := keys[k]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PUT /api/forms/:id
+// Requires If-Match with the revision the edit was based on (see GetForm's
+// ETag); a stale revision gets 409 with the current server copy.
 func UpdateForm(c *fiber.Ctx) error {
 	id := c.Params("id")
+	rev, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
 	var p formPayload
 	if err := c.BodyParser(&p); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
 	}
-	if err := validateFormPayload(p); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	if errs := validateFormPayload(p); len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+
+	var current models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&current); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if rev != anyRevision && rev != current.Revision {
+		return staleWrite(c, id)
+	}
+
+	form, err := saveDefinition(c, current, p)
+	if err != nil {
+		return err
+	}
+	setETag(c, form)
+	return c.JSON(form)
+}
+
+// saveDefinition replaces current's definition (title, fields, pages, quiz),
+// provided nobody else has written the form since current was read. Any edit
+// to a live, versioned form publishes a new FormVersion in the same step, so
+// every response is pinned to the exact definition it answered.
+func saveDefinition(c *fiber.Ctx, current models.Form, p formPayload) (models.Form, error) {
+	if current.ArchivedAt != nil {
+		return models.Form{}, fiber.NewError(fiber.StatusConflict, "form is archived")
+	}
+
+	// Responses are pinned to the FormVersion they answered, so structural edits
+	// are only unsafe for forms that collected responses before versioning.
+	structural := structuralChange(current.Fields, p.Fields)
+	if structural && current.Version == 0 && current.ResponseCount > 0 {
+		return models.Form{}, fiber.NewError(fiber.StatusConflict, "form has unversioned responses; publish it before removing, retyping or renaming fields")
 	}
+
 	now := time.Now().UTC()
-	update := bson.M{
-		"$set": bson.M{"title": p.Title, "fields": p.Fields, "updatedAt": now},
+	set := bson.M{"title": p.Title, "fields": p.Fields, "pages": p.Pages, "quiz": p.Quiz, "updatedAt": now}
+	next := current
+	next.Title, next.Fields, next.Pages, next.Quiz = p.Title, p.Fields, p.Pages, p.Quiz
+	live := current.Version > 0 && current.EffectiveStatus() == models.StatusPublished
+	changed := false
+	if live {
+		var err error
+		if changed, err = publishedDefinitionChanged(c.Context(), next); err != nil {
+			return models.Form{}, err
+		}
 	}
-	res := db.Forms().FindOneAndUpdate(c.Context(), bson.M{"_id": id}, update, nil)
-	if res.Err() != nil {
-		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	snapshot := 0
+	if changed {
+		// Live form: respondents from here on answer a new version.
+		v, err := snapshotVersion(c.Context(), next, now)
+		if err != nil {
+			return models.Form{}, err
+		}
+		snapshot = v
+		set["version"] = v
+		set["publishedAt"] = now
 	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"revision": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var form models.Form
-	if err := db.Forms().FindOne(context.Background(), bson.M{"_id": id}).Decode(&form); err != nil {
+	err := db.Forms().FindOneAndUpdate(c.Context(), revisionFilter(current.ID, current.Revision), update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if snapshot > 0 {
+			// Lost the race: the snapshot describes an edit that never landed.
+			_, _ = db.FormVersions().DeleteOne(context.Background(), bson.M{"formId": current.ID, "version": snapshot})
+		}
+		return models.Form{}, staleWrite(c, current.ID)
+	}
+	if err != nil {
+		return models.Form{}, err
+	}
+	form.Status = form.EffectiveStatus()
+	return form, nil
+}
+
+type formSummary struct {
+	ID             string     `bson:"_id" json:"id"`
+	Title          string     `bson:"title" json:"title"`
+	Status         string     `bson:"status,omitempty" json:"status"`
+	UpdatedAt      time.Time  `bson:"updatedAt" json:"updatedAt"`
+	ResponseCount  int64      `bson:"responseCount" json:"responseCount"`
+	LastResponseAt *time.Time `bson:"lastResponseAt,omitempty" json:"lastResponseAt,omitempty"`
+	Availability   string     `bson:"-" json:"availability,omitempty"`
+
+	// read only to work out Availability
+	OpensAt      *time.Time `bson:"opensAt,omitempty" json:"-"`
+	ClosesAt     *time.Time `bson:"closesAt,omitempty" json:"-"`
+	MaxResponses *int64     `bson:"maxResponses,omitempty" json:"-"`
+}
+
+// Cursors are opaque to clients: base64("<updatedAt ms>|<id>") of the last item.
+func encodeCursor(updatedAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(updatedAt.UnixMilli(), 10) + "|" + id))
+}
+
+func decodeCursor(s string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	ms, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.UnixMilli(n).UTC(), id, nil
+}
+
+// GET /api/forms?limit=20&cursor=...&status=published&prefix=Team&q=survey&view=summary
+// Ordered by updatedAt desc (ties broken by id). `prefix` matches the start of
+// the title; `q` runs a full-text search on it. view=summary returns only
+// title, status, responseCount and lastResponseAt. Archived forms are only
+// listed with archived=true.
+func ListForms(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		return fiber.NewError(fiber.StatusBadRequest, "limit must be 1..100")
+	}
+
+	conds := bson.A{bson.M{"archivedAt": bson.M{"$exists": c.QueryBool("archived")}}}
+	if s := c.Query("status"); s != "" {
+		switch s {
+		case models.StatusDraft, models.StatusPublished, models.StatusClosed:
+			conds = append(conds, statusIn(s))
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, "unknown status")
+		}
+	}
+	if p := c.Query("prefix"); p != "" {
+		conds = append(conds, bson.M{"title": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(p)}})
+	}
+	if q := c.Query("q"); q != "" {
+		conds = append(conds, bson.M{"$text": bson.M{"$search": q}})
+	}
+	if cur := c.Query("cursor"); cur != "" {
+		ts, id, err := decodeCursor(cur)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid cursor")
+		}
+		conds = append(conds, bson.M{"$or": bson.A{
+			bson.M{"updatedAt": bson.M{"$lt": ts}},
+			bson.M{"updatedAt": ts, "_id": bson.M{"$lt": id}},
+		}})
+	}
+	filter := bson.M{"$and": conds}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit) + 1)
+	summary := c.Query("view") == "summary"
+	if summary {
+		opts.SetProjection(bson.M{"title": 1, "status": 1, "updatedAt": 1, "responseCount": 1, "lastResponseAt": 1,
+			"opensAt": 1, "closesAt": 1, "maxResponses": 1})
+	}
+
+	cur, err := db.Forms().Find(c.Context(), filter, opts)
+	if err != nil {
 		return err
 	}
-	return c.JSON(form)
+	defer cur.Close(c.Context())
+
+	var next *string
+	if summary {
+		items := []formSummary{}
+		if err := cur.All(c.Context(), &items); err != nil {
+			return err
+		}
+		if len(items) > limit {
+			items = items[:limit]
+			last := items[limit-1]
+			s := encodeCursor(last.UpdatedAt, last.ID)
+			next = &s
+		}
+		now := time.Now().UTC()
+		for i, it := range items {
+			f := models.Form{Status: it.Status, ResponseCount: it.ResponseCount,
+				OpensAt: it.OpensAt, ClosesAt: it.ClosesAt, MaxResponses: it.MaxResponses}
+			items[i].Status, items[i].Availability = f.EffectiveStatus(), f.AvailabilityAt(now)
+		}
+		return c.JSON(fiber.Map{"items": items, "nextCursor": next})
+	}
+
+	items := []models.Form{}
+	if err := cur.All(c.Context(), &items); err != nil {
+		return err
+	}
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[limit-1]
+		s := encodeCursor(last.UpdatedAt, last.ID)
+		next = &s
+	}
+	now := time.Now().UTC()
+	for i := range items {
+		items[i].Status = items[i].EffectiveStatus()
+		items[i].Availability = items[i].AvailabilityAt(now)
+	}
+	return c.JSON(fiber.Map{"items": items, "nextCursor": next})
 }
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Minimal RFC 6902 (JSON Patch) implementation over documents decoded by
+// encoding/json (map[string]interface{}, []interface{}, and scalars).
+
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// errPatchTest is returned when a "test" operation does not match.
+var errPatchTest = errors.New("test operation failed")
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		t = strings.ReplaceAll(t, "~1", "/")
+		tokens[i] = strings.ReplaceAll(t, "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(tok string, n int, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return n, nil
+	}
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	limit := n - 1
+	if allowEnd {
+		limit = n
+	}
+	if i > limit {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+// walk descends to the parent of the last token and lets leaf rewrite that
+// container, storing the (possibly reallocated) container back in its parent.
+func walk(node interface{}, tokens []string, leaf func(interface{}, string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return leaf(node, tokens[0])
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", tokens[0])
+		}
+		nc, err := walk(child, tokens[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = nc
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		nc, err := walk(n[i], tokens[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = nc
+		return n, nil
+	}
+	return nil, fmt.Errorf("path segment %q not found", tokens[0])
+}
+
+func pointerGet(node interface{}, tokens []string) (interface{}, error) {
+	for _, tok := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("path segment %q not found", tok)
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(tok, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("path segment %q not found", tok)
+		}
+	}
+	return node, nil
+}
+
+func pointerAdd(doc interface{}, tokens []string, val interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return val, nil
+	}
+	return walk(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[key] = val
+			return n, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = val
+			return n, nil
+		}
+		return nil, fmt.Errorf("cannot add to %q", key)
+	})
+}
+
+func pointerRemove(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("cannot remove the document root")
+	}
+	return walk(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[key]; !ok {
+				return nil, fmt.Errorf("path segment %q not found", key)
+			}
+			delete(n, key)
+			return n, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:i], n[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("path segment %q not found", key)
+	})
+}
+
+func deepCopyJSON(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+// applyJSONPatch applies ops in order. On error the caller must discard doc,
+// which may have been partially modified.
+func applyJSONPatch(doc interface{}, ops []patchOp) (interface{}, error) {
+	for i, op := range ops {
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("op %d: %w", i, err)
+		}
+		var value interface{}
+		if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("op %d: %s requires a value", i, op.Op)
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("op %d: invalid value", i)
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			doc, err = pointerAdd(doc, path, value)
+		case "remove":
+			doc, err = pointerRemove(doc, path)
+		case "replace":
+			if _, err = pointerGet(doc, path); err == nil {
+				if len(path) == 0 {
+					doc = value
+				} else if doc, err = pointerRemove(doc, path); err == nil {
+					doc, err = pointerAdd(doc, path, value)
+				}
+			}
+		case "move", "copy":
+			var from []string
+			if from, err = parsePointer(op.From); err != nil {
+				break
+			}
+			if op.Op == "move" && strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				err = errors.New("cannot move a value into one of its children")
+				break
+			}
+			var v interface{}
+			if v, err = pointerGet(doc, from); err != nil {
+				break
+			}
+			if op.Op == "move" {
+				if doc, err = pointerRemove(doc, from); err != nil {
+					break
+				}
+			} else if v, err = deepCopyJSON(v); err != nil {
+				break
+			}
+			doc, err = pointerAdd(doc, path, v)
+		case "test":
+			var v interface{}
+			if v, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(v, value) {
+				err = errPatchTest
+			}
+		default:
+			err = fmt.Errorf("unknown op %q", op.Op)
+		}
+		if err != nil {
+			if err == errPatchTest {
+				return nil, fmt.Errorf("op %d: %w", i, err)
+			}
+			return nil, fmt.Errorf("op %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
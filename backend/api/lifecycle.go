@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"backend/db"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// statusIn matches forms whose lifecycle status is one of the given values.
+// Forms saved before statuses existed have no field and count as published.
+func statusIn(statuses ...string) bson.M {
+	or := bson.A{bson.M{"status": bson.M{"$in": statuses}}}
+	for _, s := range statuses {
+		if s == models.StatusPublished {
+			or = append(or, bson.M{"status": bson.M{"$exists": false}})
+			break
+		}
+	}
+	return bson.M{"$or": or}
+}
+
+// transition moves a form to status `to` if it is currently in one of `from`.
+// The status check and the write happen in a single FindOneAndUpdate.
+func transition(c *fiber.Ctx, to string, from []string, set bson.M) error {
+	id := c.Params("id")
+	set["status"] = to
+	set["updatedAt"] = time.Now().UTC()
+	update := bson.M{"$set": set}
+
+	filter := bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}}
+	for k, v := range statusIn(from...) {
+		filter[k] = v
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var form models.Form
+	err := db.Forms().FindOneAndUpdate(c.Context(), filter, update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		if form.ArchivedAt != nil {
+			return fiber.NewError(fiber.StatusConflict, "form is archived")
+		}
+		return fiber.NewError(fiber.StatusConflict, "cannot move form from "+form.EffectiveStatus()+" to "+to)
+	}
+	if err != nil {
+		return err
+	}
+	setETag(c, form)
+	return c.JSON(form)
+}
+
+// publishedDefinitionChanged reports whether the form's definition differs
+// from its latest published snapshot.
+func publishedDefinitionChanged(ctx context.Context, form models.Form) (bool, error) {
+	if form.Version == 0 {
+		return true, nil
+	}
+	var snap models.FormVersion
+	err := db.FormVersions().FindOne(ctx, bson.M{"formId": form.ID, "version": form.Version}).Decode(&snap)
+	if err == mongo.ErrNoDocuments {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !sameDefinition(
+		formPayload{Title: snap.Title, Fields: snap.Fields, Pages: snap.Pages, Quiz: snap.Quiz},
+		formPayload{Title: form.Title, Fields: form.Fields, Pages: form.Pages, Quiz: form.Quiz},
+	), nil
+}
+
+// sameDefinition compares definitions by their JSON encoding: one read back
+// from Mongo and one decoded from a request hold the same values in different
+// Go types (int32 vs float64 condition values, nil vs empty slices).
+func sameDefinition(a, b formPayload) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// POST /api/forms/:id/publish
+// Publishing is allowed from any status. A new immutable FormVersion is
+// written whenever the definition changed since the last publish.
+func PublishForm(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if form.ArchivedAt != nil {
+		return fiber.NewError(fiber.StatusConflict, "form is archived")
+	}
+
+	now := time.Now().UTC()
+	set := bson.M{"status": models.StatusPublished, "publishedAt": now, "updatedAt": now}
+	changed, err := publishedDefinitionChanged(c.Context(), form)
+	if err != nil {
+		return err
+	}
+	snapshot := 0
+	if changed {
+		v, err := snapshotVersion(c.Context(), form, now)
+		if err != nil {
+			return err
+		}
+		snapshot = v
+		set["version"] = v
+	}
+
+	// Only publish the definition that was snapshotted: an edit landing in
+	// between bumps the revision and makes this a stale write.
+	update := bson.M{"$set": set, "$unset": bson.M{"closedAt": ""}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = db.Forms().FindOneAndUpdate(c.Context(), revisionFilter(id, form.Revision), update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if snapshot > 0 {
+			_, _ = db.FormVersions().DeleteOne(context.Background(), bson.M{"formId": id, "version": snapshot})
+		}
+		return staleWrite(c, id)
+	}
+	if err != nil {
+		return err
+	}
+	setETag(c, form)
+	return c.JSON(form)
+}
+
+// POST /api/forms/:id/unpublish
+func UnpublishForm(c *fiber.Ctx) error {
+	return transition(c, models.StatusDraft, []string{models.StatusPublished}, bson.M{})
+}
+
+// POST /api/forms/:id/close
+func CloseForm(c *fiber.Ctx) error {
+	now := time.Now().UTC()
+	return transition(c, models.StatusClosed, []string{models.StatusPublished}, bson.M{"closedAt": now})
+}
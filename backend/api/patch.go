@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fieldOp is one field-level operation in a PATCH body:
+//
+//	{"op": "addField",    "field": {...}, "index": 2, "page": "p1"}   // index optional (append); page puts it on that page
+//	{"op": "updateField", "id": "q1", "set": {"label": "New"}}
+//	{"op": "moveField",   "id": "q1", "index": 0}
+//	{"op": "removeField", "id": "q1"}                 // also takes it off its page
+//	{"op": "setTitle",    "title": "New title"}
+//	{"op": "setPages",    "pages": [...]}
+//	{"op": "setQuiz",     "quiz": {"passPercent": 70}}   // null turns quiz mode off
+type fieldOp struct {
+	Op    string          `json:"op"`
+	ID    string          `json:"id"`
+	Index *int            `json:"index"`
+	Field *models.Field   `json:"field"`
+	Set   json.RawMessage `json:"set"`
+	Title string          `json:"title"`
+	Page  string          `json:"page"`
+	Pages []models.Page   `json:"pages"`
+	Quiz  *models.Quiz    `json:"quiz"`
+}
+
+func indexOfField(fields []models.Field, id string) int {
+	for i, f := range fields {
+		if f.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyPages copies pages deeply enough that editing FieldIDs leaves the
+// original untouched.
+func copyPages(pages []models.Page) []models.Page {
+	if pages == nil {
+		return nil
+	}
+	out := make([]models.Page, len(pages))
+	for i, pg := range pages {
+		pg.FieldIDs = append([]string(nil), pg.FieldIDs...)
+		out[i] = pg
+	}
+	return out
+}
+
+func indexOfPage(pages []models.Page, id string) int {
+	for i, pg := range pages {
+		if pg.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func applyFieldOps(p formPayload, ops []fieldOp) (formPayload, error) {
+	fields := append([]models.Field(nil), p.Fields...)
+	pages := copyPages(p.Pages)
+	for i, op := range ops {
+		switch op.Op {
+		case "setTitle":
+			p.Title = op.Title
+
+		case "setPages":
+			pages = copyPages(op.Pages)
+
+		case "setQuiz":
+			p.Quiz = op.Quiz
+
+		case "addField":
+			if op.Field == nil {
+				return p, fmt.Errorf("op %d: addField requires field", i)
+			}
+			if indexOfField(fields, op.Field.ID) >= 0 {
+				return p, fmt.Errorf("op %d: field %q already exists", i, op.Field.ID)
+			}
+			at := len(fields)
+			if op.Index != nil {
+				at = *op.Index
+			}
+			if at < 0 || at > len(fields) {
+				return p, fmt.Errorf("op %d: index out of range", i)
+			}
+			fields = append(fields, models.Field{})
+			copy(fields[at+1:], fields[at:])
+			fields[at] = *op.Field
+			if op.Page != "" {
+				pg := indexOfPage(pages, op.Page)
+				if pg < 0 {
+					return p, fmt.Errorf("op %d: page %q not found", i, op.Page)
+				}
+				pages[pg].FieldIDs = append(pages[pg].FieldIDs, op.Field.ID)
+			}
+
+		case "updateField":
+			at := indexOfField(fields, op.ID)
+			if at < 0 {
+				return p, fmt.Errorf("op %d: field %q not found", i, op.ID)
+			}
+			if len(op.Set) == 0 {
+				return p, fmt.Errorf("op %d: updateField requires set", i)
+			}
+			// Overlay the given properties onto a copy of the existing field; a
+			// JSON null clears an optional property. The copy goes through JSON
+			// because decoding straight into fields[at] would write into slices
+			// and pointers it still shares with p.Fields.
+			raw, err := json.Marshal(fields[at])
+			if err != nil {
+				return p, err
+			}
+			var f models.Field
+			if err := json.Unmarshal(raw, &f); err != nil {
+				return p, err
+			}
+			if err := json.Unmarshal(op.Set, &f); err != nil {
+				return p, fmt.Errorf("op %d: invalid set", i)
+			}
+			if f.ID != op.ID {
+				return p, fmt.Errorf("op %d: field id cannot be changed", i)
+			}
+			fields[at] = f
+
+		case "moveField":
+			at := indexOfField(fields, op.ID)
+			if at < 0 {
+				return p, fmt.Errorf("op %d: field %q not found", i, op.ID)
+			}
+			if op.Index == nil || *op.Index < 0 || *op.Index >= len(fields) {
+				return p, fmt.Errorf("op %d: index out of range", i)
+			}
+			f := fields[at]
+			fields = append(fields[:at], fields[at+1:]...)
+			to := *op.Index
+			fields = append(fields, models.Field{})
+			copy(fields[to+1:], fields[to:])
+			fields[to] = f
+
+		case "removeField":
+			at := indexOfField(fields, op.ID)
+			if at < 0 {
+				return p, fmt.Errorf("op %d: field %q not found", i, op.ID)
+			}
+			fields = append(fields[:at], fields[at+1:]...)
+			for pg := range pages {
+				ids := pages[pg].FieldIDs[:0]
+				for _, id := range pages[pg].FieldIDs {
+					if id != op.ID {
+						ids = append(ids, id)
+					}
+				}
+				pages[pg].FieldIDs = ids
+			}
+
+		default:
+			return p, fmt.Errorf("op %d: unknown op %q", i, op.Op)
+		}
+	}
+	p.Fields, p.Pages = fields, pages
+	return p, nil
+}
+
+// applyPatchDocument runs an RFC 6902 patch against {"title", "fields",
+// "pages", "quiz"} and decodes the result strictly, so paths outside the form
+// definition fail.
+func applyPatchDocument(p formPayload, ops []patchOp) (formPayload, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return p, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return p, err
+	}
+	doc, err = applyJSONPatch(doc, ops)
+	if err != nil {
+		return p, err
+	}
+	if raw, err = json.Marshal(doc); err != nil {
+		return p, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var out formPayload
+	if err := dec.Decode(&out); err != nil {
+		return p, fmt.Errorf("patched form is invalid: %v", err)
+	}
+	return out, nil
+}
+
+// validateChangedFields runs validateField only on fields that are new or
+// differ from prev; untouched fields were validated when they were saved.
+func validateChangedFields(prev, next formPayload) formErrors {
+	var errs formErrors
+	if next.Title == "" {
+		errs = append(errs, validationError{Path: "title", Code: fieldtypes.CodeRequired, Message: "title required"})
+	}
+	if len(next.Fields) == 0 {
+		errs = append(errs, validationError{Path: "fields", Code: fieldtypes.CodeRequired, Message: "fields required"})
+	}
+	old := make(map[string]models.Field, len(prev.Fields))
+	for _, f := range prev.Fields {
+		old[f.ID] = f
+	}
+	errs = append(errs, validateFields(next.Fields, func(i int) bool {
+		o, ok := old[next.Fields[i].ID]
+		return !ok || !reflect.DeepEqual(o, next.Fields[i])
+	})...)
+	errs = append(errs, validatePages(next)...)
+	return append(errs, validateQuiz(next)...)
+}
+
+// PATCH /api/forms/:id
+// Content-Type application/json-patch+json: an RFC 6902 patch applied to
+// {"title", "fields", "pages", "quiz"}. Otherwise: {"ops": [...fieldOp]}.
+// Requires If-Match like PUT; all ops apply atomically or not at all.
+func PatchForm(c *fiber.Ctx) error {
+	id := c.Params("id")
+	rev, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var current models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&current); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if rev != anyRevision && rev != current.Revision {
+		return staleWrite(c, id)
+	}
+	prev := formPayload{Title: current.Title, Fields: current.Fields, Pages: current.Pages, Quiz: current.Quiz}
+
+	var next formPayload
+	if strings.Contains(c.Get(fiber.HeaderContentType), "json-patch+json") {
+		var ops []patchOp
+		if err := json.Unmarshal(c.Body(), &ops); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JSON Patch")
+		}
+		next, err = applyPatchDocument(prev, ops)
+		if errors.Is(err, errPatchTest) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+	} else {
+		var body struct {
+			Ops []fieldOp `json:"ops"`
+		}
+		if err := json.Unmarshal(c.Body(), &body); err != nil || len(body.Ops) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "ops required")
+		}
+		next, err = applyFieldOps(prev, body.Ops)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if errs := validateChangedFields(prev, next); len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+
+	form, err := saveDefinition(c, current, next)
+	if err != nil {
+		return err
+	}
+	setETag(c, form)
+	return c.JSON(form)
+}
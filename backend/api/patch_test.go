@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"backend/models"
+)
+
+func TestApplyFieldOpsLeavesPrevUntouched(t *testing.T) {
+	minLen := 1
+	prev := formPayload{
+		Title: "Survey",
+		Fields: []models.Field{{
+			ID:        "q1",
+			Label:     "Pick",
+			Type:      "multipleChoice",
+			Options:   []string{"a", "b"},
+			MinLength: &minLen,
+			VisibleIf: &models.Condition{All: []models.Condition{{Field: "q0", Op: "equals", Value: "x"}}},
+		}},
+		Pages: []models.Page{{ID: "p1", FieldIDs: []string{"q1"}}},
+	}
+	before, err := json.Marshal(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []fieldOp{
+		{Op: "updateField", ID: "q1", Set: json.RawMessage(`{"options":["A","b"],"minLength":5,"visibleIf":{"all":[{"field":"q0","op":"equals","value":"y"}]}}`)},
+		{Op: "removeField", ID: "q1"},
+	}
+	next, err := applyFieldOps(prev, ops[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next.Fields[0].Options; !reflect.DeepEqual(got, []string{"A", "b"}) {
+		t.Fatalf("next options = %v", got)
+	}
+	if _, err := applyFieldOps(prev, ops[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := json.Marshal(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("prev changed:\nbefore %s\nafter  %s", before, after)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jung-kurt/gofpdf"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GET /api/forms/:id/responses/:responseId/export.pdf
+// One response, answer by answer, with signatures drawn. Fields come from
+// the version the response was submitted against when it is known. Graded
+// quiz responses also get their score and each question's marks.
+func ExportResponsePDF(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	var resp models.Response
+	if err := db.Responses().FindOne(c.Context(), bson.M{"_id": c.Params("responseId"), "formId": id}).Decode(&resp); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "response not found")
+	}
+	fields := form.Fields
+	if resp.Version > 0 {
+		var v models.FormVersion
+		if err := db.FormVersions().FindOne(c.Context(), bson.M{"formId": id, "version": resp.Version}).Decode(&v); err == nil {
+			fields = v.Fields
+		}
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Response — %s", form.Title), false)
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.Cell(0, 10, form.Title)
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Response %s · submitted %s", resp.ID, resp.SubmittedAt.Format(time.RFC1123)))
+	pdf.Ln(10)
+	marks := map[string]models.QuestionScore{}
+	if resp.Score != nil {
+		pdfScoreHeader(pdf, *resp.Score)
+		for _, q := range resp.Score.Questions {
+			marks[q.FieldID] = q
+		}
+	}
+
+	for _, f := range fields {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.MultiCell(0, 6, f.Label, "", "", false)
+		pdf.SetFont("Helvetica", "", 10)
+
+		v, ok := resp.Answers[f.ID]
+		switch {
+		case !ok || v == nil:
+			pdf.MultiCell(0, 5, "-", "", "", false)
+		default:
+			t, known := fieldtypes.Lookup(f.Type)
+			if r, ok := t.(fieldtypes.PDFAnswerRenderer); known && ok {
+				if err := r.RenderPDFAnswer(pdf, f, v); err != nil {
+					pdf.MultiCell(0, 5, fmt.Sprintf("(unreadable %s answer)", f.Type), "", "", false)
+				}
+				break
+			}
+			text := fieldtypes.FormatValue(v)
+			if known {
+				text = t.FormatForExport(f, v)
+			}
+			pdf.MultiCell(0, 5, text, "", "", false)
+		}
+		if q, ok := marks[f.ID]; ok {
+			pdfQuestionMark(pdf, q)
+		}
+		pdf.Ln(3)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return err
+	}
+	c.Attachment(fmt.Sprintf("%s_response_%s.pdf", safeName(form.Title), resp.ID))
+	c.Type("pdf")
+	return c.Send(out.Bytes())
+}
+
+// pdfScoreHeader prints a graded response's total and pass/fail result.
+func pdfScoreHeader(pdf *gofpdf.Fpdf, s models.Score) {
+	result := "Not passed"
+	pdf.SetTextColor(190, 40, 40)
+	if s.Passed {
+		result = "Passed"
+		pdf.SetTextColor(30, 130, 60)
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.Cell(0, 7, fmt.Sprintf("Score %s / %s (%.0f%%) · %s", formatPoints(s.Points), formatPoints(s.MaxPoints), s.Percent, result))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(10)
+}
+
+// pdfQuestionMark prints the points a question earned and, unless it was
+// answered fully right, the answer key it was graded against.
+func pdfQuestionMark(pdf *gofpdf.Fpdf, q models.QuestionScore) {
+	pdf.SetFont("Helvetica", "I", 9)
+	line := fmt.Sprintf("%s / %s points", formatPoints(q.Points), formatPoints(q.MaxPoints))
+	if q.Correct {
+		pdf.SetTextColor(30, 130, 60)
+		line = "Correct · " + line
+	} else {
+		pdf.SetTextColor(190, 40, 40)
+		line = fmt.Sprintf("Incorrect · %s · correct answer: %s", line, strings.Join(q.CorrectAnswers, ", "))
+	}
+	pdf.MultiCell(0, 5, line, "", "", false)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// formatPoints drops the decimals from whole point values.
+func formatPoints(p float64) string {
+	return strconv.FormatFloat(math.Round(p*100)/100, 'f', -1, 64)
+}
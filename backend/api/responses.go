@@ -3,15 +3,21 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"fmt"
+	"log"
+	"math"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"backend/analytics"
 	"backend/db"
+	"backend/fieldtypes"
+	"backend/logic"
 	"backend/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,175 +30,125 @@ import (
 // Validation (server-side, mirrors frontend rules)
 // -----------------------------------------------------------------------------
 
-func validateAnswers(form models.Form, ans map[string]interface{}) map[string]string {
+// Fields the respondent was not shown — hidden by a condition or on a page
+// their answers skip (see fillCalculated) — are not required and must be left
+// unanswered.
+func validateAnswers(form models.Form, ans map[string]interface{}, visible map[string]bool) map[string]string {
 	errs := map[string]string{}
 
 	for _, f := range form.Fields {
+		t, ok := fieldtypes.Lookup(f.Type)
+		if !ok {
+			continue
+		}
 		v, present := ans[f.ID]
-
-		// Required checks
-		if f.Required {
-			switch f.Type {
-			case "text":
-				s := ""
-				if present {
-					if sv, ok := v.(string); ok {
-						s = strings.TrimSpace(sv)
-					}
-				}
-				if s == "" {
-					errs[f.ID] = "Required"
-					continue
-				}
-			case "multipleChoice":
-				s := ""
-				if present {
-					if sv, ok := v.(string); ok {
-						s = sv
-					}
-				}
-				if s == "" {
-					errs[f.ID] = "Required"
-					continue
-				}
-			case "checkboxes":
-				ok := false
-				if present {
-					if arr, ok2 := v.([]interface{}); ok2 {
-						ok = len(arr) > 0
-					} else if arrS, ok2 := v.([]string); ok2 {
-						ok = len(arrS) > 0
-					} else if arrA, ok2 := v.(primitive.A); ok2 {
-						ok = len(arrA) > 0
-					}
-				}
-				if !ok {
-					errs[f.ID] = "Required"
-					continue
-				}
-			case "rating":
-				_, ok := v.(float64) // JSON numbers -> float64
-				if !ok {
-					errs[f.ID] = "Required"
-					continue
-				}
+		if !visible[f.ID] {
+			if present && !fieldtypes.IsEmpty(v) {
+				errs[f.ID] = "Field is hidden"
 			}
+			continue
 		}
-
-		// Type-specific rules
-		switch f.Type {
-		case "text":
-			if !present {
-				break
-			}
-			s, _ := v.(string)
-			if f.MinLength != nil && len([]rune(s)) < *f.MinLength {
-				errs[f.ID] = "Min length not met"
-			}
-			if f.MaxLength != nil && len([]rune(s)) > *f.MaxLength {
-				errs[f.ID] = "Max length exceeded"
-			}
-
-		case "multipleChoice":
-			if !present || len(f.Options) == 0 {
-				break
-			}
-			s, _ := v.(string)
-			ok := false
-			for _, o := range f.Options {
-				if s == o {
-					ok = true
-					break
-				}
-			}
-			if !ok {
-				errs[f.ID] = "Invalid option"
-			}
-
-		case "checkboxes":
-			if !present || len(f.Options) == 0 {
-				break
-			}
-			// Build allowed set
-			allowed := map[string]struct{}{}
-			for _, o := range f.Options {
-				allowed[o] = struct{}{}
-			}
-
-			count := 0
-			switch arr := v.(type) {
-			case []interface{}:
-				for _, item := range arr {
-					if s, ok := item.(string); ok {
-						if _, ok := allowed[s]; ok {
-							count++
-						} else {
-							errs[f.ID] = "Invalid option"
-							break
-						}
-					}
-				}
-			case []string:
-				for _, s := range arr {
-					if _, ok := allowed[s]; ok {
-						count++
-					} else {
-						errs[f.ID] = "Invalid option"
-						break
-					}
-				}
-			case primitive.A:
-				for _, item := range arr {
-					if s, ok := item.(string); ok {
-						if _, ok := allowed[s]; ok {
-							count++
-						} else {
-							errs[f.ID] = "Invalid option"
-							break
-						}
-					}
-				}
-			default:
-				// ignore unsupported types here
-			}
-
-			if f.MinChecked != nil && count < *f.MinChecked {
-				errs[f.ID] = "Below min selections"
-			}
-			if f.MaxChecked != nil && count > *f.MaxChecked {
-				errs[f.ID] = "Above max selections"
-			}
-
-		case "rating":
-			if !present {
-				break
-			}
-			scale := 5
-			if f.Scale != nil {
-				scale = *f.Scale
-			}
-			min := 0
-			if f.Min != nil {
-				min = *f.Min
-			}
-			num, ok := v.(float64)
-			if !ok {
-				errs[f.ID] = "Invalid rating"
-				break
-			}
-			if int(num) < min || int(num) > scale {
-				errs[f.ID] = "Out of range"
-			}
+		if msg := t.ValidateAnswer(f, v, present); msg != "" {
+			errs[f.ID] = msg
 		}
 	}
 
 	return errs
 }
 
+// normalizeAnswers replaces each validated answer with the form its field
+// type stores (e.g. trimmed, canonicalized), and drops hidden fields' blanks.
+func normalizeAnswers(form models.Form, ans map[string]interface{}, visible map[string]bool) {
+	for _, f := range form.Fields {
+		v, present := ans[f.ID]
+		if !present {
+			continue
+		}
+		if !visible[f.ID] {
+			delete(ans, f.ID)
+			continue
+		}
+		if t, ok := fieldtypes.Lookup(f.Type); ok {
+			ans[f.ID] = t.Normalize(f, v)
+		}
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Handlers: submit/list responses
 // -----------------------------------------------------------------------------
 
+// normalizedAnswers returns answers as they will be stored: normalized by
+// their field types, with invalid answers (which fail validation anyway) left
+// out. Conditions and expressions read this, so a number sent as "5" compares
+// as 5.
+func normalizedAnswers(form models.Form, ans map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(ans))
+	for _, f := range form.Fields {
+		v, present := ans[f.ID]
+		if !present {
+			continue
+		}
+		t, ok := fieldtypes.Lookup(f.Type)
+		if !ok {
+			normalized[f.ID] = v
+			continue
+		}
+		if t.ValidateAnswer(f, v, true) == "" {
+			normalized[f.ID] = t.Normalize(f, v)
+		}
+	}
+	return normalized
+}
+
+// fillCalculated replaces any submitted values for calculated fields with
+// the server's results and returns which fields the respondent was shown
+// (see logic.Walk), keeping results only for those. Expressions and
+// conditions both see normalizedAnswers.
+func fillCalculated(form models.Form, ans map[string]interface{}) map[string]bool {
+	for _, f := range form.Fields {
+		if _, computed := fieldtypes.ExpressionOf(f); computed {
+			delete(ans, f.ID)
+		}
+	}
+	normalized := normalizedAnswers(form, ans)
+	for id, v := range logic.Calculate(form.Fields, normalized) {
+		ans[id] = v
+		normalized[id] = v
+	}
+	_, shown := logic.Walk(form.Fields, form.Pages, normalized)
+	for _, f := range form.Fields {
+		if _, computed := fieldtypes.ExpressionOf(f); computed && !shown[f.ID] {
+			delete(ans, f.ID)
+		}
+	}
+	return shown
+}
+
+// acceptingResponses rejects submissions (and uploads) to forms that are
+// deleted, unpublished, closed, outside their schedule or full. Submissions
+// re-check the schedule and cap atomically in reserveResponse.
+func acceptingResponses(form models.Form, now time.Time) error {
+	if form.ArchivedAt != nil {
+		return fiber.NewError(fiber.StatusGone, "form has been deleted")
+	}
+	switch form.EffectiveStatus() {
+	case models.StatusDraft:
+		return fiber.NewError(fiber.StatusForbidden, "form is not published")
+	case models.StatusClosed:
+		return fiber.NewError(fiber.StatusForbidden, "form is closed")
+	}
+	switch form.AvailabilityAt(now) {
+	case models.AvailabilityNotYetOpen:
+		return fiber.NewError(fiber.StatusForbidden, "form opens at "+form.OpensAt.UTC().Format(time.RFC3339))
+	case models.AvailabilityEnded:
+		return fiber.NewError(fiber.StatusForbidden, "form is closed")
+	case models.AvailabilityFull:
+		return fiber.NewError(fiber.StatusForbidden, "form has reached its response limit")
+	}
+	return nil
+}
+
 func SubmitResponse(c *fiber.Ctx) error {
 	id := c.Params("id")
 
@@ -201,6 +157,9 @@ func SubmitResponse(c *fiber.Ctx) error {
 	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "form not found")
 	}
+	if err := acceptingResponses(form, time.Now().UTC()); err != nil {
+		return err
+	}
 
 	// Parse payload
 	var payload struct {
@@ -213,27 +172,50 @@ func SubmitResponse(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "answers required")
 	}
 
+	shown := fillCalculated(form, payload.Answers)
+
 	// Validate
-	errs := validateAnswers(form, payload.Answers)
+	errs := validateAnswers(form, payload.Answers, shown)
 	if len(errs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": errs})
 	}
+	normalizeAnswers(form, payload.Answers, shown)
+	score := logic.Grade(form, payload.Answers, shown)
 
 	// Insert response
 	resp := models.Response{
 		ID:          primitive.NewObjectID().Hex(),
 		FormID:      id,
+		Version:     form.Version,
 		SubmittedAt: time.Now().UTC(),
 		Answers:     payload.Answers,
+		Score:       score,
+	}
+	errs, err := claimUploads(c.Context(), form, resp.Answers, resp.ID)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": errs})
+	}
+	// Count the response before storing it, so the cap holds however many
+	// submissions race; a failed insert gives the slot back.
+	if err := reserveResponse(c, id, resp.SubmittedAt); err != nil {
+		releaseUploads(c.Context(), resp.ID)
+		return err
 	}
 	if _, err := db.Responses().InsertOne(c.Context(), resp); err != nil {
+		releaseUploads(c.Context(), resp.ID)
+		if _, rbErr := db.Forms().UpdateByID(context.Background(), id, bson.M{"$inc": bson.M{"responseCount": -1}}); rbErr != nil {
+			// The slot stays taken until reserveResponse recounts a full form.
+			log.Printf("form %s: giving back response slot failed: %v", id, rbErr)
+		}
 		return err
 	}
 
 	// Update form metadata
 	now := resp.SubmittedAt
 	_, _ = db.Forms().UpdateByID(c.Context(), id, bson.M{
-		"$inc": bson.M{"responseCount": 1},
 		"$set": bson.M{"lastResponseAt": now, "updatedAt": now},
 	})
 
@@ -258,13 +240,29 @@ func ListResponses(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"items": resps})
 }
 
-// Helper used by analytics + exports
+// Helper used by analytics + exports.
+// With ?version=N the form's definition (title, fields, pages, quiz) is
+// replaced by that published snapshot and only responses to it are returned; otherwise responses from all
+// versions are merged onto the current fields (see mergeVersions).
 func loadFormAndResponses(c *fiber.Ctx, id string) (models.Form, []models.Response, error) {
 	var form models.Form
 	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
 		return models.Form{}, nil, fiber.NewError(fiber.StatusNotFound, "form not found")
 	}
-	cur, err := db.Responses().Find(c.Context(), bson.M{"formId": id}, nil)
+
+	filter := bson.M{"formId": id}
+	version := c.Query("version")
+	if version != "" {
+		snap, err := findVersion(c, id, version)
+		if err != nil {
+			return models.Form{}, nil, err
+		}
+		form.Title, form.Fields = snap.Title, snap.Fields
+		form.Pages, form.Quiz = snap.Pages, snap.Quiz
+		filter["version"] = snap.Version
+	}
+
+	cur, err := db.Responses().Find(c.Context(), filter, nil)
 	if err != nil {
 		return models.Form{}, nil, err
 	}
@@ -274,6 +272,14 @@ func loadFormAndResponses(c *fiber.Ctx, id string) (models.Form, []models.Respon
 	if err := cur.All(c.Context(), &resps); err != nil {
 		return models.Form{}, nil, err
 	}
+	if version != "" {
+		form.ResponseCount = int64(len(resps))
+		return form, resps, nil
+	}
+	resps, err = mergeVersions(c.Context(), form, resps)
+	if err != nil {
+		return models.Form{}, nil, err
+	}
 	return form, resps, nil
 }
 
@@ -303,48 +309,6 @@ func safeName(s string) string {
 	return s
 }
 
-func toString(v interface{}) string {
-	switch t := v.(type) {
-	case string:
-		return t
-	case float64:
-		return fmt.Sprintf("%g", t)
-	case int:
-		return fmt.Sprintf("%d", t)
-	case int32:
-		return fmt.Sprintf("%d", t)
-	case int64:
-		return fmt.Sprintf("%d", t)
-	default:
-		return fmt.Sprintf("%v", t)
-	}
-}
-
-func joinCheckboxes(v interface{}) string {
-	switch arr := v.(type) {
-	case []string:
-		return strings.Join(arr, "; ")
-	case []interface{}:
-		out := make([]string, 0, len(arr))
-		for _, it := range arr {
-			if s, ok := it.(string); ok {
-				out = append(out, s)
-			}
-		}
-		return strings.Join(out, "; ")
-	case primitive.A:
-		out := make([]string, 0, len(arr))
-		for _, it := range arr {
-			if s, ok := it.(string); ok {
-				out = append(out, s)
-			}
-		}
-		return strings.Join(out, "; ")
-	default:
-		return ""
-	}
-}
-
 // GET /api/forms/:id/responses/export.csv
 func ExportResponsesCSV(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -356,37 +320,62 @@ func ExportResponsesCSV(c *fiber.Ctx) error {
 	buf := &bytes.Buffer{}
 	w := csv.NewWriter(buf)
 
-	// Header: responseId, submittedAt, then each field label (fallback to id)
-	header := []string{"responseId", "submittedAt"}
+	// Header: responseId, submittedAt, version, then each field label (fallback
+	// to id); multi-column types get "label: column" per column. Quizzes end
+	// with the score columns.
+	header := []string{"responseId", "submittedAt", "version"}
 	for _, f := range form.Fields {
 		col := f.Label
 		if strings.TrimSpace(col) == "" {
 			col = f.ID
 		}
+		if t, ok := fieldtypes.Lookup(f.Type); ok {
+			if m, ok := t.(fieldtypes.MultiColumnExporter); ok {
+				for _, sub := range m.ExportColumns(f) {
+					header = append(header, col+": "+sub)
+				}
+				continue
+			}
+		}
 		header = append(header, col)
 	}
+	if form.Quiz != nil {
+		header = append(header, "score", "maxScore", "scorePercent", "passed")
+	}
 	if err := w.Write(header); err != nil {
 		return err
 	}
 
 	// Rows
 	for _, r := range resps {
-		row := []string{r.ID, r.SubmittedAt.Format(time.RFC3339)}
+		row := []string{r.ID, r.SubmittedAt.Format(time.RFC3339), strconv.Itoa(r.Version)}
 		for _, f := range form.Fields {
 			v, ok := r.Answers[f.ID]
+			t, known := fieldtypes.Lookup(f.Type)
+			if l, links := t.(fieldtypes.LinkExporter); known && links {
+				row = append(row, l.ExportLinks(f, v, c.BaseURL()+"/api"))
+				continue
+			}
+			if m, multi := t.(fieldtypes.MultiColumnExporter); known && multi {
+				row = append(row, m.ExportCells(f, v)...)
+				continue
+			}
 			if !ok || v == nil {
 				row = append(row, "")
 				continue
 			}
-			switch f.Type {
-			case "text", "multipleChoice":
-				row = append(row, toString(v))
-			case "checkboxes":
-				row = append(row, joinCheckboxes(v))
-			case "rating":
-				row = append(row, toString(v))
-			default:
-				row = append(row, toString(v))
+			if known {
+				row = append(row, t.FormatForExport(f, v))
+			} else {
+				row = append(row, fieldtypes.FormatValue(v))
+			}
+		}
+		if form.Quiz != nil {
+			if s := r.Score; s != nil {
+				row = append(row, formatPoints(s.Points), formatPoints(s.MaxPoints),
+					strconv.FormatFloat(math.Round(s.Percent*10)/10, 'f', -1, 64), strconv.FormatBool(s.Passed))
+			} else {
+				row = append(row, "", "", "", "")
 			}
 		}
 		if err := w.Write(row); err != nil {
@@ -440,36 +429,20 @@ func ExportResponsesPDF(c *fiber.Ctx) error {
 
 		pdf.SetFont("Helvetica", "", 10)
 		meta := f.Summary
-		if f.Type == "rating" && f.Average != nil && f.Scale != nil {
-			meta += fmt.Sprintf(" · avg %.2f / %d", *f.Average, *f.Scale)
-		}
-		if f.Type == "checkboxes" && f.Average != nil {
-			meta += fmt.Sprintf(" · avg selected %.2f", *f.Average)
+		t, _ := fieldtypes.Lookup(f.Type)
+		if d, ok := t.(fieldtypes.PDFDetailer); ok {
+			if detail := d.PDFDetail(f); detail != "" {
+				meta += " · " + detail
+			}
 		}
 		pdf.Cell(0, 5, meta)
 		pdf.Ln(6)
 
-		// Table header
-		pdf.SetFillColor(240, 240, 240)
-		pdf.SetFont("Helvetica", "B", 9)
-		pdf.CellFormat(110, 6, "Option", "1", 0, "", true, 0, "")
-		pdf.CellFormat(30, 6, "Count", "1", 0, "R", true, 0, "")
-		pdf.CellFormat(30, 6, "Percent", "1", 0, "R", true, 0, "")
-		pdf.Ln(-1)
-
-		// Rows
-		total := float64(max(1, f.ResponseN))
-		pdf.SetFont("Helvetica", "", 9)
-		for _, b := range f.Bars {
-			pct := (float64(b.Value) / total) * 100.0
-			label := b.Label
-			if label == "" {
-				label = "-"
-			}
-			pdf.CellFormat(110, 6, label, "1", 0, "", false, 0, "")
-			pdf.CellFormat(30, 6, fmt.Sprintf("%d", b.Value), "1", 0, "R", false, 0, "")
-			pdf.CellFormat(30, 6, fmt.Sprintf("%.0f%%", pct), "1", 0, "R", false, 0, "")
-			pdf.Ln(-1)
+		// Types with their own layout draw it; the rest get the bar table.
+		if r, ok := t.(fieldtypes.PDFSectionRenderer); ok {
+			r.RenderPDFSection(pdf, f)
+		} else {
+			fieldtypes.PDFBarTable(pdf, f)
 		}
 		pdf.Ln(4)
 	}
@@ -499,10 +472,3 @@ func ExportResponsesPDF(c *fiber.Ctx) error {
 	c.Type("pdf")
 	return c.Send(out.Bytes())
 }
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
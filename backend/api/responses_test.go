@@ -0,0 +1,83 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"backend/logic"
+	"backend/models"
+)
+
+func TestVisibilityReadsNormalizedAnswers(t *testing.T) {
+	form := models.Form{Fields: []models.Field{
+		{ID: "age", Type: "number", Label: "Age"},
+		{ID: "why", Type: "text", Label: "Why", Required: true,
+			VisibleIf: &models.Condition{Field: "age", Op: "greaterThan", Value: 18.0}},
+	}}
+	tests := []struct {
+		name    string
+		answers map[string]interface{}
+		errs    map[string]string
+		stored  map[string]interface{}
+	}{
+		{"numeric string shows the field",
+			map[string]interface{}{"age": " 25 ", "why": "because"},
+			map[string]string{},
+			map[string]interface{}{"age": 25.0, "why": "because"}},
+		{"numeric string requires the field",
+			map[string]interface{}{"age": "25"},
+			map[string]string{"why": "Required"},
+			nil},
+		{"numeric string hides the field",
+			map[string]interface{}{"age": "9", "why": "because"},
+			map[string]string{"why": "Field is hidden"},
+			nil},
+	}
+	for _, tt := range tests {
+		shown := fillCalculated(form, tt.answers)
+		errs := validateAnswers(form, tt.answers, shown)
+		if !reflect.DeepEqual(errs, tt.errs) {
+			t.Errorf("%s: errors %v, want %v", tt.name, errs, tt.errs)
+			continue
+		}
+		if tt.stored == nil {
+			continue
+		}
+		normalizeAnswers(form, tt.answers, shown)
+		if !reflect.DeepEqual(tt.answers, tt.stored) {
+			t.Errorf("%s: stored %v, want %v", tt.name, tt.answers, tt.stored)
+		}
+	}
+}
+
+func TestGradeStoredAnswers(t *testing.T) {
+	form := models.Form{
+		Quiz: &models.Quiz{PassPercent: 50},
+		Fields: []models.Field{
+			{ID: "q1", Type: "multipleChoice", Options: []string{"A", "B"}, CorrectAnswers: []string{"A"}},
+			{ID: "age", Type: "number"},
+			{ID: "q2", Type: "multipleChoice", Options: []string{"C", "D"}, CorrectAnswers: []string{"C"},
+				VisibleIf: &models.Condition{Field: "age", Op: "greaterThan", Value: 18.0}},
+		},
+	}
+	tests := []struct {
+		name            string
+		answers         map[string]interface{}
+		points, maxPts  float64
+		questionsScored int
+	}{
+		{"hidden question left out", map[string]interface{}{"q1": "A", "age": "9"}, 1, 1, 1},
+		{"shown by a numeric string", map[string]interface{}{"q1": "A", "age": "25", "q2": "C"}, 2, 2, 2},
+	}
+	for _, tt := range tests {
+		shown := fillCalculated(form, tt.answers)
+		if errs := validateAnswers(form, tt.answers, shown); len(errs) > 0 {
+			t.Fatalf("%s: %v", tt.name, errs)
+		}
+		normalizeAnswers(form, tt.answers, shown)
+		s := logic.Grade(form, tt.answers, shown)
+		if s == nil || s.Points != tt.points || s.MaxPoints != tt.maxPts || len(s.Questions) != tt.questionsScored {
+			t.Errorf("%s: score %+v, want %v / %v over %d questions", tt.name, s, tt.points, tt.maxPts, tt.questionsScored)
+		}
+	}
+}
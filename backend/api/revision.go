@@ -0,0 +1,65 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"backend/db"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// anyRevision is returned by ifMatch for `If-Match: *`.
+const anyRevision = -1
+
+// revisionFilter matches form id only while it is still at revision rev.
+// Forms saved before revisions existed have no field and are at revision 0.
+func revisionFilter(id string, rev int64) bson.M {
+	if rev == 0 {
+		return bson.M{"_id": id, "$or": bson.A{
+			bson.M{"revision": 0},
+			bson.M{"revision": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": id, "revision": rev}
+}
+
+func setETag(c *fiber.Ctx, form models.Form) {
+	c.Set(fiber.HeaderETag, `"`+strconv.FormatInt(form.Revision, 10)+`"`)
+}
+
+// ifMatch reads the revision a write was based on from the If-Match header.
+// Writes without one are rejected with 428 so a client can never blindly
+// overwrite someone else's edit.
+func ifMatch(c *fiber.Ctx) (int64, error) {
+	h := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if h == "" {
+		return 0, fiber.NewError(fiber.StatusPreconditionRequired, "If-Match header required")
+	}
+	if h == "*" {
+		return anyRevision, nil
+	}
+	h = strings.Trim(strings.TrimPrefix(h, "W/"), `"`)
+	rev, err := strconv.ParseInt(h, 10, 64)
+	if err != nil || rev < 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid If-Match header")
+	}
+	return rev, nil
+}
+
+// staleWrite answers 409 with the server's current copy so the client can
+// rebase its edit.
+func staleWrite(c *fiber.Ctx, id string) error {
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	form.Status = form.EffectiveStatus()
+	setETag(c, form)
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":   "form was modified by someone else",
+		"current": form,
+	})
+}
@@ -9,20 +9,49 @@ import (
 var hub = realtime.NewHub()
 
 func Register(r fiber.Router) {
+	r.Use(limitBody)
 	r.Get("/", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"ok": true, "api": true}) })
 
 	forms := r.Group("/forms")
+	forms.Get("/", ListForms)
 	forms.Post("/", CreateForm)
+	forms.Post("/import", ImportDefinition)
 	forms.Get("/:id", GetForm)
+	forms.Get("/:id/respond", GetRespondentForm)
 	forms.Put("/:id", UpdateForm)
+	forms.Patch("/:id", PatchForm)
+	forms.Delete("/:id", DeleteForm)
+	forms.Post("/:id/restore", RestoreForm)
+	forms.Post("/:id/duplicate", DuplicateForm)
+	forms.Post("/:id/fields/:fieldId/choices/import", ImportChoices)
+	forms.Get("/:id/definition", ExportDefinition)
+	forms.Get("/:id/schema", GetAnswersSchema)
+
+	forms.Post("/:id/publish", PublishForm)
+	forms.Post("/:id/unpublish", UnpublishForm)
+	forms.Post("/:id/close", CloseForm)
+	forms.Put("/:id/schedule", UpdateSchedule)
+
+	forms.Get("/:id/versions", ListVersions)
+	forms.Get("/:id/versions/diff", DiffVersions)
+	forms.Get("/:id/versions/:version", GetVersion)
 
 	forms.Post("/:id/responses", SubmitResponse)
 	forms.Get("/:id/responses", ListResponses)
+	forms.Post("/:id/uploads", UploadFile)
+	r.Get("/uploads/:uploadId", DownloadUpload)
 
 	// NEW: exports
 	forms.Get("/:id/responses/export.csv", ExportResponsesCSV)
 	forms.Get("/:id/responses/export.pdf", ExportResponsesPDF)
+	forms.Get("/:id/responses/:responseId/export.pdf", ExportResponsePDF)
 
 	forms.Get("/:id/analytics", GetAnalytics)
 	forms.Get("/:id/analytics/longpoll", LongPollAnalytics)
+
+	templates := r.Group("/templates")
+	templates.Get("/", ListTemplates)
+	templates.Post("/", CreateTemplate)
+	templates.Get("/:id", GetTemplate)
+	templates.Delete("/:id", DeleteTemplate)
 }
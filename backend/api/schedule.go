@@ -0,0 +1,187 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reservationGrace is longer than any submission takes between reserving a
+// slot and storing the response. A count untouched for that long has no
+// inserts in flight and can be checked against the stored responses.
+const reservationGrace = time.Minute
+
+// reserveResponse counts one more response against form id, but only while
+// the form accepts responses at now: published, inside its schedule and
+// below its cap. The check and the increment are one FindOneAndUpdate, so
+// concurrent submissions can never push responseCount past maxResponses.
+func reserveResponse(c *fiber.Ctx, id string, now time.Time) error {
+	return reserve(c, id, now, true)
+}
+
+func reserve(c *fiber.Ctx, id string, now time.Time, recount bool) error {
+	filter := bson.M{
+		"_id":        id,
+		"archivedAt": bson.M{"$exists": false},
+		"$and": bson.A{
+			statusIn(models.StatusPublished),
+			bson.M{"$or": bson.A{bson.M{"opensAt": bson.M{"$exists": false}}, bson.M{"opensAt": bson.M{"$lte": now}}}},
+			bson.M{"$or": bson.A{bson.M{"closesAt": bson.M{"$exists": false}}, bson.M{"closesAt": bson.M{"$gt": now}}}},
+			bson.M{"$or": bson.A{
+				bson.M{"maxResponses": bson.M{"$exists": false}},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$responseCount", "$maxResponses"}}},
+			}},
+		},
+	}
+	update := bson.M{"$inc": bson.M{"responseCount": 1}, "$set": bson.M{"reservedAt": now}}
+	err := db.Forms().FindOneAndUpdate(c.Context(), filter, update).Err()
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+	// Say why: the form changed since it was loaded, or the window or cap was
+	// reached in the meantime.
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if recount && form.AvailabilityAt(now) == models.AvailabilityFull {
+		freed, err := recountResponses(c, form, now)
+		if err != nil {
+			return err
+		}
+		if freed {
+			return reserve(c, id, now, false)
+		}
+	}
+	if err := acceptingResponses(form, now); err != nil {
+		return err
+	}
+	return fiber.NewError(fiber.StatusConflict, "form changed while submitting; retry")
+}
+
+// recountResponses corrects a responseCount that ran ahead of the stored
+// responses, which happens when a submission dies between reserving its slot
+// and storing the response (or giving the slot back). It only acts when no
+// reservation was made within reservationGrace, and only if the count is
+// still the one it checked, so in-flight submissions keep their slots.
+func recountResponses(c *fiber.Ctx, form models.Form, now time.Time) (bool, error) {
+	if form.ReservedAt != nil && now.Sub(*form.ReservedAt) < reservationGrace {
+		return false, nil
+	}
+	n, err := db.Responses().CountDocuments(c.Context(), bson.M{"formId": form.ID})
+	if err != nil {
+		return false, err
+	}
+	if n >= form.ResponseCount {
+		return false, nil
+	}
+	filter := bson.M{"_id": form.ID, "responseCount": form.ResponseCount, "reservedAt": bson.M{"$exists": false}}
+	if form.ReservedAt != nil {
+		filter["reservedAt"] = *form.ReservedAt
+	}
+	res, err := db.Forms().UpdateOne(c.Context(), filter, bson.M{"$set": bson.M{"responseCount": n}})
+	if err != nil {
+		return false, err
+	}
+	if res.ModifiedCount == 1 {
+		log.Printf("form %s: responseCount %d corrected to %d stored responses", form.ID, form.ResponseCount, n)
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+type schedulePayload struct {
+	OpensAt      *time.Time `json:"opensAt,omitempty"`
+	ClosesAt     *time.Time `json:"closesAt,omitempty"`
+	MaxResponses *int64     `json:"maxResponses,omitempty"`
+}
+
+func scheduleOf(form models.Form) schedulePayload {
+	return schedulePayload{OpensAt: form.OpensAt, ClosesAt: form.ClosesAt, MaxResponses: form.MaxResponses}
+}
+
+// validate reports problems with paths under prefix ("" or "settings.").
+func (p schedulePayload) validate(prefix string) formErrors {
+	var errs formErrors
+	if p.OpensAt != nil && p.ClosesAt != nil && !p.OpensAt.Before(*p.ClosesAt) {
+		errs = append(errs, validationError{Path: prefix + "closesAt", Code: fieldtypes.CodeMinOverMax, Message: "closesAt must be after opensAt"})
+	}
+	if p.MaxResponses != nil && *p.MaxResponses < 1 {
+		errs = append(errs, validationError{Path: prefix + "maxResponses", Code: fieldtypes.CodeOutOfRange, Message: "maxResponses must be at least 1"})
+	}
+	return errs
+}
+
+// apply sets the schedule on a form about to be inserted.
+func (p schedulePayload) apply(form *models.Form) {
+	form.MaxResponses = p.MaxResponses
+	if p.OpensAt != nil {
+		t := p.OpensAt.UTC()
+		form.OpensAt = &t
+	}
+	if p.ClosesAt != nil {
+		t := p.ClosesAt.UTC()
+		form.ClosesAt = &t
+	}
+}
+
+// PUT /api/forms/:id/schedule   {"opensAt", "closesAt", "maxResponses"}
+// Replaces the whole schedule; a missing or null property removes that
+// limit. Lowering maxResponses below the responses already collected stops
+// new submissions but keeps existing responses. Like any edit it bumps the
+// revision, so writes based on an older ETag get 409.
+func UpdateSchedule(c *fiber.Ctx) error {
+	var p schedulePayload
+	if err := c.BodyParser(&p); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
+	}
+	if errs := p.validate(""); len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+
+	set, unset := bson.M{"updatedAt": time.Now().UTC()}, bson.M{}
+	if p.OpensAt != nil {
+		set["opensAt"] = p.OpensAt.UTC()
+	} else {
+		unset["opensAt"] = ""
+	}
+	if p.ClosesAt != nil {
+		set["closesAt"] = p.ClosesAt.UTC()
+	} else {
+		unset["closesAt"] = ""
+	}
+	if p.MaxResponses != nil {
+		set["maxResponses"] = *p.MaxResponses
+	} else {
+		unset["maxResponses"] = ""
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"revision": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	id := c.Params("id")
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var form models.Form
+	err := db.Forms().FindOneAndUpdate(c.Context(), bson.M{"_id": id, "archivedAt": bson.M{"$exists": false}}, update, opts).Decode(&form)
+	if err == mongo.ErrNoDocuments {
+		if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Err(); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		return fiber.NewError(fiber.StatusConflict, "form is archived")
+	}
+	if err != nil {
+		return err
+	}
+	form.Status = form.EffectiveStatus()
+	form.Availability = form.AvailabilityAt(time.Now().UTC())
+	setETag(c, form)
+	return c.JSON(form)
+}
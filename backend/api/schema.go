@@ -0,0 +1,63 @@
+package api
+
+import (
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/logic"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// fieldSchema mirrors the answer rules of the field's type.
+func fieldSchema(f models.Field) map[string]interface{} {
+	s := map[string]interface{}{}
+	if t, ok := fieldtypes.Lookup(f.Type); ok {
+		s = t.Schema(f)
+	}
+	s["title"] = f.Label
+	return s
+}
+
+// answersSchema describes the `answers` object accepted by SubmitResponse.
+func answersSchema(form models.Form, id string) map[string]interface{} {
+	props := make(map[string]interface{}, len(form.Fields))
+	required := []string{}
+	always := logic.AlwaysShown(form.Fields, form.Pages) // skipped fields may be left out
+	for _, f := range form.Fields {
+		props[f.ID] = fieldSchema(f)
+		if f.Required && always[f.ID] {
+			required = append(required, f.ID)
+		}
+	}
+	return map[string]interface{}{
+		"$schema":    jsonSchemaDialect,
+		"$id":        id,
+		"title":      form.Title,
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+// GET /api/forms/:id/schema?version=N
+// JSON Schema (draft 2020-12) for the `answers` object of a submission.
+func GetAnswersSchema(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if v := c.Query("version"); v != "" {
+		snap, err := findVersion(c, id, v)
+		if err != nil {
+			return err
+		}
+		form.Title, form.Fields, form.Pages = snap.Title, snap.Fields, snap.Pages
+	}
+
+	return c.JSON(answersSchema(form, c.BaseURL()+c.OriginalURL()), "application/schema+json")
+}
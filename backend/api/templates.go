@@ -0,0 +1,200 @@
+package api
+
+import (
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	"backend/db"
+	"backend/logic"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const fieldIDAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// newFieldID matches the 8-char base36 ids the builder generates.
+func newFieldID() string {
+	b := make([]byte, 8)
+	for i := range b {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(fieldIDAlphabet))))
+		b[i] = fieldIDAlphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+// cloneDefinition copies fields under fresh ids so the copy shares nothing
+// with the source form's responses or analytics. References to fields from
+// conditions and pages are rewritten to the new ids.
+func cloneDefinition(fields []models.Field, pages []models.Page) ([]models.Field, []models.Page) {
+	ids := make(map[string]string, len(fields))
+	for _, f := range fields {
+		ids[f.ID] = newFieldID()
+	}
+	out := make([]models.Field, len(fields))
+	for i, f := range fields {
+		f.ID = ids[f.ID]
+		if f.VisibleIf != nil {
+			c := logic.RenameRefs(*f.VisibleIf, ids)
+			f.VisibleIf = &c
+		}
+		if f.Expression != "" {
+			// Unparseable expressions fail validation anyway; keep them as is.
+			f.Expression, _ = logic.RenameExprRefs(f.Expression, ids)
+		}
+		f.Options = append([]string(nil), f.Options...)
+		f.CorrectAnswers = append([]string(nil), f.CorrectAnswers...)
+		f.Choices = append([]models.Choice(nil), f.Choices...)
+		f.Rows = append([]models.MatrixRow(nil), f.Rows...)
+		f.Columns = append([]string(nil), f.Columns...)
+		out[i] = f
+	}
+	var outPages []models.Page
+	if pages != nil {
+		outPages = make([]models.Page, len(pages))
+		for i, pg := range pages {
+			fieldIDs := make([]string, len(pg.FieldIDs))
+			for j, id := range pg.FieldIDs {
+				fieldIDs[j] = ids[id]
+			}
+			pg.FieldIDs = fieldIDs
+			branches := make([]models.Branch, len(pg.Branches))
+			for j, b := range pg.Branches {
+				b.When = logic.RenameRefs(b.When, ids)
+				branches[j] = b
+			}
+			if pg.Branches != nil {
+				pg.Branches = branches
+			}
+			outPages[i] = pg
+		}
+	}
+	return out, outPages
+}
+
+// POST /api/forms/:id/duplicate   body optional: {"title": "..."}
+func DuplicateForm(c *fiber.Ctx) error {
+	var src models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": c.Params("id")}).Decode(&src); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	var body struct {
+		Title string `json:"title"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
+		}
+	}
+	if body.Title == "" {
+		body.Title = src.Title + " (copy)"
+	}
+	fields, pages := cloneDefinition(src.Fields, src.Pages)
+	return insertForm(c, formPayload{Title: body.Title, Fields: fields, Pages: pages, Quiz: src.Quiz}, schedulePayload{})
+}
+
+func createFromTemplate(c *fiber.Ctx, templateID string) error {
+	var t models.Template
+	if err := db.Templates().FindOne(c.Context(), bson.M{"_id": templateID}).Decode(&t); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	var body struct {
+		Title string `json:"title"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
+		}
+	}
+	if body.Title == "" {
+		body.Title = t.Title
+	}
+	fields, pages := cloneDefinition(t.Fields, t.Pages)
+	return insertForm(c, formPayload{Title: body.Title, Fields: fields, Pages: pages, Quiz: t.Quiz}, schedulePayload{})
+}
+
+// POST /api/templates                {"name", "description", "title", "fields", "pages", "quiz"}
+// POST /api/templates?fromForm=<id>  {"name", "description"}
+func CreateTemplate(c *fiber.Ctx) error {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		formPayload
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	if fid := c.Query("fromForm"); fid != "" {
+		var form models.Form
+		if err := db.Forms().FindOne(c.Context(), bson.M{"_id": fid}).Decode(&form); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		body.Title, body.Fields, body.Pages, body.Quiz = form.Title, form.Fields, form.Pages, form.Quiz
+		if body.Name == "" {
+			body.Name = form.Title
+		}
+	}
+	if body.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name required")
+	}
+	if errs := validateFormPayload(body.formPayload); len(errs) > 0 {
+		return invalidForm(c, errs)
+	}
+
+	t := models.Template{
+		ID:          primitive.NewObjectID().Hex(),
+		Name:        body.Name,
+		Description: body.Description,
+		Title:       body.Title,
+		Fields:      body.Fields,
+		Pages:       body.Pages,
+		Quiz:        body.Quiz,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if _, err := db.Templates().InsertOne(c.Context(), t); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(t)
+}
+
+// GET /api/templates
+func ListTemplates(c *fiber.Ctx) error {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cur, err := db.Templates().Find(c.Context(), bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(c.Context())
+
+	items := []models.Template{}
+	if err := cur.All(c.Context(), &items); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+// GET /api/templates/:id
+func GetTemplate(c *fiber.Ctx) error {
+	var t models.Template
+	if err := db.Templates().FindOne(c.Context(), bson.M{"_id": c.Params("id")}).Decode(&t); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	return c.JSON(t)
+}
+
+// DELETE /api/templates/:id
+func DeleteTemplate(c *fiber.Ctx) error {
+	res, err := db.Templates().DeleteOne(c.Context(), bson.M{"_id": c.Params("id")})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
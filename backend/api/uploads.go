@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backend/db"
+	"backend/fieldtypes"
+	"backend/models"
+	"backend/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Request body limits: uploads may carry one file plus multipart overhead,
+// everything else is held to Fiber's default.
+const (
+	UploadBodyLimit  = fieldtypes.MaxFileSize + 1<<20
+	defaultBodyLimit = 4 << 20
+)
+
+// limitBody rejects a request whose body is over its route's limit. A
+// declared length is checked before the body is read; a chunked body has no
+// length, so it is read here through a limit instead of by the handler.
+func limitBody(c *fiber.Ctx) error {
+	limit := defaultBodyLimit
+	if c.Method() == fiber.MethodPost && strings.HasSuffix(c.Path(), "/uploads") {
+		limit = UploadBodyLimit
+	}
+	if c.Request().Header.ContentLength() > limit {
+		return bodyTooLarge(c)
+	}
+	if c.Request().IsBodyStream() {
+		body, err := io.ReadAll(io.LimitReader(c.Context().RequestBodyStream(), int64(limit)+1))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "could not read request body")
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		c.Request().SetBody(body)
+	}
+	return c.Next()
+}
+
+func bodyTooLarge(c *fiber.Ctx) error {
+	// The rest of the body is still on the wire, so the connection cannot be
+	// reused.
+	c.Context().SetConnectionClose()
+	return fiber.ErrRequestEntityTooLarge
+}
+
+// UploadTTL is how long an upload waits to be claimed by a response; after
+// that SweepUploads deletes it, so abandoned uploads do not fill the disk.
+const UploadTTL = 24 * time.Hour
+
+// POST /api/forms/:id/uploads   multipart: fieldId, file
+// Stores one file for a file field and returns its id, which the respondent
+// then lists in the field's answer within UploadTTL. Uploads are claimed by
+// the response that references them and cannot be reused.
+func UploadFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var form models.Form
+	if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if err := acceptingResponses(form, time.Now().UTC()); err != nil {
+		return err
+	}
+	at := indexOfField(form.Fields, c.FormValue("fieldId"))
+	if at < 0 || form.Fields[at].Type != "file" {
+		return fiber.NewError(fiber.StatusBadRequest, "fieldId must name a file field")
+	}
+	f := form.Fields[at]
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file required")
+	}
+	if fh.Size > fieldtypes.FileSizeLimit(f) {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "file is too large")
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	ctype, err := uploadContentType(fh.Header.Get("Content-Type"), fh.Filename, src)
+	if err != nil {
+		return err
+	}
+	if !fieldtypes.AllowsType(f, ctype) {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "file type not allowed")
+	}
+
+	up := models.Upload{
+		ID:          primitive.NewObjectID().Hex(),
+		FormID:      id,
+		FieldID:     f.ID,
+		Name:        filepath.Base(fh.Filename),
+		ContentType: ctype,
+		Size:        fh.Size,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := storage.Default().Put(c.Context(), up.ID, src); err != nil {
+		return err
+	}
+	if _, err := db.Uploads().InsertOne(c.Context(), up); err != nil {
+		_ = storage.Default().Delete(c.Context(), up.ID)
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(up)
+}
+
+// uploadContentType decides what an upload is from its contents. The
+// client's claim (header, else file extension) is only used when sniffing
+// finds nothing more specific than plain text, a zip container or unknown
+// binary, as with office documents. src is rewound afterwards.
+func uploadContentType(claimed, filename string, src io.ReadSeeker) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	switch sniffed {
+	case "application/octet-stream", "text/plain", "application/zip":
+	default:
+		return sniffed, nil
+	}
+	if claimed == "" || claimed == "application/octet-stream" {
+		claimed = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if t, _, err := mime.ParseMediaType(claimed); err == nil && t != "" {
+		return t, nil
+	}
+	return sniffed, nil
+}
+
+// inlineImageTypes are shown in the browser when downloaded; anything else,
+// SVG included, is sent as an attachment.
+var inlineImageTypes = map[string]bool{
+	"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true,
+}
+
+// GET /api/uploads/:uploadId
+func DownloadUpload(c *fiber.Ctx) error {
+	var up models.Upload
+	if err := db.Uploads().FindOne(c.Context(), bson.M{"_id": c.Params("uploadId")}).Decode(&up); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "upload not found")
+	}
+	rc, err := storage.Default().Open(c.Context(), up.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "upload not found")
+	}
+	if err != nil {
+		return err
+	}
+	if inlineImageTypes[up.ContentType] {
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": up.Name}))
+	} else {
+		c.Attachment(up.Name)
+	}
+	c.Set(fiber.HeaderContentType, up.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(rc, int(up.Size))
+}
+
+// claimUploads marks the uploads each file answer lists as belonging to
+// response respID. An id that is unknown, made for another field, or already
+// claimed fails that field; on any failure every claim is released.
+func claimUploads(ctx context.Context, form models.Form, ans map[string]interface{}, respID string) (map[string]string, error) {
+	errs := map[string]string{}
+	for _, f := range form.Fields {
+		if f.Type != "file" {
+			continue
+		}
+		ids := fieldtypes.FileIDs(ans[f.ID])
+		if len(ids) == 0 {
+			continue
+		}
+		res, err := db.Uploads().UpdateMany(ctx, bson.M{
+			"_id":        bson.M{"$in": ids},
+			"formId":     form.ID,
+			"fieldId":    f.ID,
+			"responseId": bson.M{"$exists": false},
+			"createdAt":  bson.M{"$gt": time.Now().UTC().Add(-UploadTTL)},
+		}, bson.M{"$set": bson.M{"responseId": respID}})
+		if err != nil {
+			releaseUploads(ctx, respID)
+			return nil, err
+		}
+		if res.ModifiedCount != int64(len(ids)) {
+			errs[f.ID] = "Unknown or already used upload"
+		}
+	}
+	if len(errs) > 0 {
+		releaseUploads(ctx, respID)
+	}
+	return errs, nil
+}
+
+// releaseUploads undoes claimUploads when the response is not stored.
+func releaseUploads(ctx context.Context, respID string) {
+	_, _ = db.Uploads().UpdateMany(ctx, bson.M{"responseId": respID}, bson.M{"$unset": bson.M{"responseId": ""}})
+}
+
+// SweepUploads deletes uploads left unclaimed for longer than UploadTTL.
+// Metadata goes first, conditioned on still being unclaimed, so a response
+// claiming the upload at the same moment either wins or fails cleanly.
+func SweepUploads(ctx context.Context) error {
+	cur, err := db.Uploads().Find(ctx, bson.M{
+		"responseId": bson.M{"$exists": false},
+		"createdAt":  bson.M{"$lte": time.Now().UTC().Add(-UploadTTL)},
+	})
+	if err != nil {
+		return err
+	}
+	var ups []models.Upload
+	if err := cur.All(ctx, &ups); err != nil {
+		return err
+	}
+	for _, up := range ups {
+		res, err := db.Uploads().DeleteOne(ctx, bson.M{"_id": up.ID, "responseId": bson.M{"$exists": false}})
+		if err != nil {
+			return err
+		}
+		if res.DeletedCount == 0 {
+			continue
+		}
+		if err := storage.Default().Delete(ctx, up.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUploads removes every upload made for a form, contents first so a
+// failure leaves metadata to retry from.
+func deleteUploads(ctx context.Context, formID string) error {
+	cur, err := db.Uploads().Find(ctx, bson.M{"formId": formID})
+	if err != nil {
+		return err
+	}
+	var ups []models.Upload
+	if err := cur.All(ctx, &ups); err != nil {
+		return err
+	}
+	for _, up := range ups {
+		if err := storage.Default().Delete(ctx, up.ID); err != nil {
+			return err
+		}
+	}
+	_, err = db.Uploads().DeleteMany(ctx, bson.M{"formId": formID})
+	return err
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestLimitBody(t *testing.T) {
+	app := fiber.New(fiber.Config{BodyLimit: UploadBodyLimit, StreamRequestBody: true, DisableStartupMessage: true})
+	app.Use(limitBody)
+	echoLen := func(c *fiber.Ctx) error { return c.SendString(strconv.Itoa(len(c.Body()))) }
+	app.Put("/api/forms/f1", echoLen)
+	app.Post("/api/forms/f1/uploads", echoLen)
+
+	// Served over a listener rather than app.Test, which always sends a
+	// Content-Length.
+	ln := fasthttputil.NewInmemoryListener()
+	go app.Listener(ln)
+	defer app.Shutdown()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) { return ln.Dial() },
+	}}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"small", fiber.MethodPut, "/api/forms/f1", 1 << 10, false, fiber.StatusOK},
+		{"small chunked", fiber.MethodPut, "/api/forms/f1", 1 << 10, true, fiber.StatusOK},
+		{"at the limit chunked", fiber.MethodPut, "/api/forms/f1", defaultBodyLimit, true, fiber.StatusOK},
+		{"over the limit", fiber.MethodPut, "/api/forms/f1", defaultBodyLimit + 1, false, fiber.StatusRequestEntityTooLarge},
+		{"over the limit chunked", fiber.MethodPut, "/api/forms/f1", 3 * defaultBodyLimit, true, fiber.StatusRequestEntityTooLarge},
+		{"upload chunked", fiber.MethodPost, "/api/forms/f1/uploads", 2 * defaultBodyLimit, true, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		var body io.Reader = bytes.NewReader(bytes.Repeat([]byte("x"), tt.size))
+		if tt.chunked {
+			// hide the length so the request is sent chunked
+			body = io.MultiReader(body)
+		}
+		req, _ := http.NewRequest(tt.method, "http://api"+tt.path, body)
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+			continue
+		}
+		if tt.want == fiber.StatusOK && string(got) != strconv.Itoa(tt.size) {
+			t.Errorf("%s: handler read %s bytes, want %d", tt.name, got, tt.size)
+		}
+	}
+}
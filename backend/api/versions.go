@@ -0,0 +1,416 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"backend/db"
+	"backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// snapshotVersion stores the form's current title and fields as version
+// form.Version+1. The unique (formId, version) index rejects a concurrent
+// snapshot of the same version, which surfaces as a 409.
+func snapshotVersion(ctx context.Context, form models.Form, now time.Time) (int, error) {
+	v := models.FormVersion{
+		ID:          primitive.NewObjectID().Hex(),
+		FormID:      form.ID,
+		Version:     form.Version + 1,
+		Title:       form.Title,
+		Fields:      form.Fields,
+		Pages:       form.Pages,
+		Quiz:        form.Quiz,
+		PublishedAt: now,
+	}
+	if _, err := db.FormVersions().InsertOne(ctx, v); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, fiber.NewError(fiber.StatusConflict, "form was published concurrently; reload and retry")
+		}
+		return 0, err
+	}
+	if v.Version == 1 {
+		// Responses collected before versioning existed answered exactly this
+		// definition, since structural edits were refused while unversioned.
+		// If they cannot be pinned, withdraw the snapshot so a retry can take
+		// version 1 again.
+		_, err := db.Responses().UpdateMany(ctx,
+			bson.M{"formId": form.ID, "version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}})
+		if err != nil {
+			_, _ = db.FormVersions().DeleteOne(context.Background(), bson.M{"_id": v.ID})
+			return 0, err
+		}
+	}
+	return v.Version, nil
+}
+
+// loadVersions returns every published snapshot of a form keyed by version.
+func loadVersions(ctx context.Context, formID string) (map[int]models.FormVersion, error) {
+	cur, err := db.FormVersions().Find(ctx, bson.M{"formId": formID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var vs []models.FormVersion
+	if err := cur.All(ctx, &vs); err != nil {
+		return nil, err
+	}
+	out := make(map[int]models.FormVersion, len(vs))
+	for _, v := range vs {
+		out[v.Version] = v
+	}
+	return out, nil
+}
+
+// mergeVersions prepares responses from every version for analysis against
+// the form's current fields. Answers are reconciled with the version the
+// response was submitted against: answers to a field that had a different
+// type are dropped, and values naming an option, choice, matrix row or
+// column the current field no longer has (see answerKeys) are removed,
+// rather than misread.
+func mergeVersions(ctx context.Context, form models.Form, resps []models.Response) ([]models.Response, error) {
+	versions, err := loadVersions(ctx, form.ID)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]models.Field, len(form.Fields))
+	for _, f := range form.Fields {
+		current[f.ID] = f
+	}
+	// gone[version][field id] holds the answer keys that version had and the
+	// current field lacks.
+	gone := make(map[int]map[string]map[string]bool, len(versions))
+	for n, snap := range versions {
+		byField := map[string]map[string]bool{}
+		for _, f := range snap.Fields {
+			cur, ok := current[f.ID]
+			if !ok || cur.Type != f.Type {
+				continue
+			}
+			keys := map[string]bool{}
+			for _, k := range answerKeys(cur) {
+				keys[k] = true
+			}
+			for _, k := range answerKeys(f) {
+				if !keys[k] {
+					if byField[f.ID] == nil {
+						byField[f.ID] = map[string]bool{}
+					}
+					byField[f.ID][k] = true
+				}
+			}
+		}
+		gone[n] = byField
+	}
+
+	for i, r := range resps {
+		snap, ok := versions[r.Version]
+		if !ok {
+			continue
+		}
+		var answers map[string]interface{}
+		edit := func() map[string]interface{} {
+			if answers == nil {
+				answers = make(map[string]interface{}, len(r.Answers))
+				for k, v := range r.Answers {
+					answers[k] = v
+				}
+			}
+			return answers
+		}
+		for _, f := range snap.Fields {
+			v, answered := r.Answers[f.ID]
+			if !answered {
+				continue
+			}
+			cur, ok := current[f.ID]
+			if !ok {
+				continue
+			}
+			if cur.Type != f.Type {
+				delete(edit(), f.ID)
+				continue
+			}
+			if keys := gone[r.Version][f.ID]; keys != nil {
+				if keys["multiSelect:"+strconv.FormatBool(f.MultiSelect)] {
+					v = reshapeMatrix(v, cur.MultiSelect)
+				}
+				if pruned, keep := pruneAnswer(v, keys, ""); keep {
+					edit()[f.ID] = pruned
+				} else {
+					delete(edit(), f.ID)
+				}
+			}
+		}
+		if answers != nil {
+			resps[i].Answers = answers
+		}
+	}
+	return resps, nil
+}
+
+// reshapeMatrix converts a matrix answer between single-select rows (a
+// column) and multi-select rows (a list of columns). Multi-select rows with
+// more than one column have no single-select reading and are dropped.
+func reshapeMatrix(v interface{}, multi bool) interface{} {
+	var rows map[string]interface{}
+	switch a := v.(type) {
+	case map[string]interface{}:
+		rows = a
+	case primitive.M:
+		rows = a
+	case primitive.D:
+		rows = make(map[string]interface{}, len(a))
+		for _, e := range a {
+			rows[e.Key] = e.Value
+		}
+	default:
+		return v
+	}
+	out := make(map[string]interface{}, len(rows))
+	for row, col := range rows {
+		if multi {
+			if s, ok := col.(string); ok {
+				out[row] = []interface{}{s}
+			}
+			continue
+		}
+		var items []interface{}
+		switch a := col.(type) {
+		case []interface{}:
+			items = a
+		case primitive.A:
+			items = a
+		}
+		if len(items) == 1 {
+			if s, ok := items[0].(string); ok {
+				out[row] = s
+			}
+		}
+	}
+	return out
+}
+
+// pruneAnswer removes the parts of a stored answer that refer to removed
+// answer keys: option or choice values, and for matrix answers row ids and
+// column values (prefix "column:" inside a row). keep is false when nothing
+// meaningful is left.
+func pruneAnswer(v interface{}, removed map[string]bool, prefix string) (interface{}, bool) {
+	isGone := func(s string) bool {
+		if prefix != "" {
+			return removed[prefix+s]
+		}
+		return removed[s] || removed["choice:"+s]
+	}
+	switch a := v.(type) {
+	case string:
+		return a, !isGone(a)
+	case []interface{}, primitive.A, []string:
+		var items []interface{}
+		switch a := a.(type) {
+		case []string:
+			for _, s := range a {
+				items = append(items, s)
+			}
+		case primitive.A:
+			items = a
+		case []interface{}:
+			items = a
+		}
+		out := []interface{}{}
+		for _, it := range items {
+			if s, ok := it.(string); ok && isGone(s) {
+				continue
+			}
+			out = append(out, it)
+		}
+		return out, len(out) > 0
+	case map[string]interface{}, primitive.D, primitive.M:
+		rows := map[string]interface{}{}
+		switch a := a.(type) {
+		case map[string]interface{}:
+			rows = a
+		case primitive.M:
+			rows = a
+		case primitive.D:
+			for _, e := range a {
+				rows[e.Key] = e.Value
+			}
+		}
+		out := map[string]interface{}{}
+		for row, col := range rows {
+			if removed["row:"+row] {
+				continue
+			}
+			if col, ok := pruneAnswer(col, removed, "column:"); ok {
+				out[row] = col
+			}
+		}
+		return out, len(out) > 0
+	}
+	return v, true
+}
+
+// -----------------------------------------------------------------------------
+// Handlers
+// -----------------------------------------------------------------------------
+
+// GET /api/forms/:id/versions
+func ListVersions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	opts := options.Find().SetSort(bson.M{"version": 1})
+	cur, err := db.FormVersions().Find(c.Context(), bson.M{"formId": id}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(c.Context())
+
+	vs := []models.FormVersion{}
+	if err := cur.All(c.Context(), &vs); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"items": vs})
+}
+
+func findVersion(c *fiber.Ctx, formID string, raw string) (models.FormVersion, error) {
+	var v models.FormVersion
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return v, fiber.NewError(fiber.StatusBadRequest, "invalid version")
+	}
+	if err := db.FormVersions().FindOne(c.Context(), bson.M{"formId": formID, "version": n}).Decode(&v); err != nil {
+		return v, fiber.NewError(fiber.StatusNotFound, "version not found")
+	}
+	return v, nil
+}
+
+// GET /api/forms/:id/versions/:version
+func GetVersion(c *fiber.Ctx) error {
+	v, err := findVersion(c, c.Params("id"), c.Params("version"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(v)
+}
+
+type fieldChange struct {
+	FieldID    string        `json:"fieldId"`
+	Change     string        `json:"change"` // added | removed | modified
+	Properties []string      `json:"properties,omitempty"`
+	From       *models.Field `json:"from,omitempty"`
+	To         *models.Field `json:"to,omitempty"`
+}
+
+type versionDiff struct {
+	FormID     string        `json:"formId"`
+	From       int           `json:"from"`
+	To         int           `json:"to"`
+	Title      bool          `json:"titleChanged"`
+	Order      bool          `json:"orderChanged"`
+	Structural bool          `json:"structural"`
+	Fields     []fieldChange `json:"fields"`
+}
+
+// changedProperties lists the JSON properties that differ between two
+// definitions of the same field.
+func changedProperties(a, b models.Field) []string {
+	toMap := func(f models.Field) map[string]interface{} {
+		m := map[string]interface{}{}
+		raw, _ := json.Marshal(f)
+		_ = json.Unmarshal(raw, &m)
+		return m
+	}
+	am, bm := toMap(a), toMap(b)
+	keys := map[string]struct{}{}
+	for k := range am {
+		keys[k] = struct{}{}
+	}
+	for k := range bm {
+		keys[k] = struct{}{}
+	}
+	var out []string
+	for k := range keys {
+		if !reflect.DeepEqual(am[k], bm[k]) {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func diffVersions(from, to models.FormVersion) versionDiff {
+	d := versionDiff{
+		FormID:     to.FormID,
+		From:       from.Version,
+		To:         to.Version,
+		Title:      from.Title != to.Title,
+		Structural: structuralChange(from.Fields, to.Fields),
+		Fields:     []fieldChange{},
+	}
+
+	prev := make(map[string]models.Field, len(from.Fields))
+	var prevOrder []string
+	for _, f := range from.Fields {
+		prev[f.ID] = f
+	}
+	next := make(map[string]struct{}, len(to.Fields))
+	var nextOrder []string
+	for i := range to.Fields {
+		f := to.Fields[i]
+		next[f.ID] = struct{}{}
+		old, ok := prev[f.ID]
+		if !ok {
+			d.Fields = append(d.Fields, fieldChange{FieldID: f.ID, Change: "added", To: &f})
+			continue
+		}
+		nextOrder = append(nextOrder, f.ID)
+		if props := changedProperties(old, f); len(props) > 0 {
+			d.Fields = append(d.Fields, fieldChange{FieldID: f.ID, Change: "modified", Properties: props, From: &old, To: &f})
+		}
+	}
+	for i := range from.Fields {
+		f := from.Fields[i]
+		if _, ok := next[f.ID]; !ok {
+			d.Fields = append(d.Fields, fieldChange{FieldID: f.ID, Change: "removed", From: &f})
+			continue
+		}
+		prevOrder = append(prevOrder, f.ID)
+	}
+	d.Order = !reflect.DeepEqual(prevOrder, nextOrder)
+	return d
+}
+
+// GET /api/forms/:id/versions/diff?from=1&to=2
+// `to` defaults to the latest published version.
+func DiffVersions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	from, err := findVersion(c, id, c.Query("from"))
+	if err != nil {
+		return err
+	}
+
+	toRaw := c.Query("to")
+	if toRaw == "" {
+		var form models.Form
+		if err := db.Forms().FindOne(c.Context(), bson.M{"_id": id}).Decode(&form); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		toRaw = strconv.Itoa(form.Version)
+	}
+	to, err := findVersion(c, id, toRaw)
+	if err != nil {
+		return err
+	}
+	return c.JSON(diffVersions(from, to))
+}
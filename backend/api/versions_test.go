@@ -0,0 +1,47 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPruneAnswer(t *testing.T) {
+	removed := map[string]bool{"old": true, "choice:gone": true, "row:r2": true, "column:Bad": true}
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+		keep bool
+	}{
+		{"kept option", "new", "new", true},
+		{"removed option", "old", "old", false},
+		{"removed choice", "gone", "gone", false},
+		{"checkboxes", primitive.A{"old", "new"}, []interface{}{"new"}, true},
+		{"checkboxes all removed", []interface{}{"old"}, []interface{}{}, false},
+		{"matrix", primitive.D{{Key: "r1", Value: "Good"}, {Key: "r2", Value: "Good"}, {Key: "r3", Value: "Bad"}},
+			map[string]interface{}{"r1": "Good"}, true},
+		{"matrix multi", map[string]interface{}{"r1": []interface{}{"Bad", "Good"}},
+			map[string]interface{}{"r1": []interface{}{"Good"}}, true},
+		{"other value", 3.5, 3.5, true},
+	}
+	for _, tt := range tests {
+		got, keep := pruneAnswer(tt.in, removed, "")
+		if keep != tt.keep || (keep && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: got %v, %v; want %v, %v", tt.name, got, keep, tt.want, tt.keep)
+		}
+	}
+}
+
+func TestReshapeMatrix(t *testing.T) {
+	single := primitive.D{{Key: "r1", Value: "Good"}}
+	multi := reshapeMatrix(single, true)
+	if want := map[string]interface{}{"r1": []interface{}{"Good"}}; !reflect.DeepEqual(multi, want) {
+		t.Errorf("to multi = %v, want %v", multi, want)
+	}
+	back := reshapeMatrix(map[string]interface{}{"r1": primitive.A{"Good"}, "r2": primitive.A{"Good", "Bad"}}, false)
+	if want := map[string]interface{}{"r1": "Good"}; !reflect.DeepEqual(back, want) {
+		t.Errorf("to single = %v, want %v", back, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -34,10 +35,17 @@ func Connect(uri, name string) error {
 
 	// Indexes
 	Forms().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: map[string]int{"updatedAt": -1}})
+	Forms().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.M{"title": "text"}})
 	Responses().Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys:    map[string]int{"formId": 1, "submittedAt": -1},
 		Options: options.Index().SetBackground(true),
 	})
+	FormVersions().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "formId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	Uploads().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "formId", Value: 1}}})
+	Uploads().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "createdAt", Value: 1}}}) // unclaimed upload sweep
 	return nil
 }
 
@@ -54,3 +62,15 @@ func Forms() *mongo.Collection {
 func Responses() *mongo.Collection {
 	return DB().Collection("responses")
 }
+
+func FormVersions() *mongo.Collection {
+	return DB().Collection("formVersions")
+}
+
+func Templates() *mongo.Collection {
+	return DB().Collection("templates")
+}
+
+func Uploads() *mongo.Collection {
+	return DB().Collection("uploads")
+}
@@ -0,0 +1,42 @@
+package fieldtypes
+
+import "sort"
+
+// topBars turns category counts into bars sorted by count (then label),
+// keeping the n largest and rolling the rest into one "Others" bar.
+func topBars(counts map[string]int, n int) []Bar {
+	bars := make([]Bar, 0, len(counts))
+	for label, c := range counts {
+		bars = append(bars, Bar{Label: label, Value: c})
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Value != bars[j].Value {
+			return bars[i].Value > bars[j].Value
+		}
+		return bars[i].Label < bars[j].Label
+	})
+	if n <= 0 || len(bars) <= n {
+		return bars
+	}
+	others := 0
+	for _, b := range bars[n:] {
+		others += b.Value
+	}
+	return append(bars[:n], Bar{Label: "Others", Value: others})
+}
+
+// breakdownSize is how many categories breakdown charts show before "Others".
+const breakdownSize = 10
+
+// aggregateBreakdown counts answers by key(value), skipping values for which
+// key returns "".
+func aggregateBreakdown(answers []Answer, key func(string) string) []Bar {
+	counts := map[string]int{}
+	for _, a := range answers {
+		s, _ := a.Value.(string)
+		if k := key(s); k != "" {
+			counts[k]++
+		}
+	}
+	return topBars(counts, breakdownSize)
+}
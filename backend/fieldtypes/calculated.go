@@ -0,0 +1,78 @@
+package fieldtypes
+
+import (
+	"math"
+	"strings"
+
+	"backend/models"
+)
+
+// calculatedType holds a value the server computes from other answers at
+// submit time (see logic.Calculate). Expressions are parsed and checked for
+// unknown references and cycles by the API, which can see the whole form.
+type calculatedType struct{}
+
+func init() { Register(calculatedType{}) }
+
+func (calculatedType) Name() string { return "calculated" }
+
+func (calculatedType) ValidateDefinition(f models.Field) []Problem {
+	if strings.TrimSpace(f.Expression) == "" {
+		return []Problem{{"expression", CodeRequired, "calculated field requires an expression"}}
+	}
+	return nil
+}
+
+func (calculatedType) Expression(f models.Field) string { return f.Expression }
+
+// ValidateAnswer accepts anything: the stored value is always the server's.
+func (calculatedType) ValidateAnswer(f models.Field, v interface{}, present bool) string { return "" }
+
+func (calculatedType) Normalize(f models.Field, v interface{}) interface{} { return v }
+
+// Results are summarized by their kind: numbers like a number field,
+// true/false as two bars, anything else by most common value.
+func (calculatedType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	var nums []float64
+	counts := map[string]int{}
+	bools := false
+	for _, a := range answers {
+		if n, ok := asFloat(a.Value); ok {
+			nums = append(nums, n)
+			continue
+		}
+		if _, ok := a.Value.(bool); ok {
+			bools = true
+		}
+		if a.Value != nil {
+			counts[FormatValue(a.Value)]++
+		}
+	}
+	an.Summary = "Calculated"
+	switch {
+	case len(nums) > 0 && len(counts) == 0:
+		integers := true
+		for _, n := range nums {
+			integers = integers && n == math.Trunc(n)
+		}
+		an.Stats = numericStats(nums)
+		an.Average = &an.Stats.Mean
+		an.Bars = histogram(nums, integers)
+	case bools && len(counts) <= 2:
+		an.Bars = []Bar{{Label: "true", Value: counts["true"]}, {Label: "false", Value: counts["false"]}}
+	default:
+		for _, n := range nums {
+			counts[FormatValue(n)]++
+		}
+		an.Bars = topBars(counts, breakdownSize)
+	}
+}
+
+func (calculatedType) PDFDetail(an FieldAnalytics) string { return formatStats(an.Stats) }
+
+func (calculatedType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+// Schema marks the field read-only: submitted values are ignored.
+func (calculatedType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"readOnly": true}
+}
@@ -0,0 +1,145 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"backend/models"
+)
+
+type checkboxesType struct{}
+
+func init() { Register(checkboxesType{}) }
+
+func (checkboxesType) Name() string { return "checkboxes" }
+
+func (checkboxesType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if len(f.Options) == 0 {
+		ps = append(ps, Problem{"options", CodeRequired, "checkboxes requires options"})
+	}
+	if f.MinChecked != nil && *f.MinChecked < 0 {
+		ps = append(ps, Problem{"minChecked", CodeOutOfRange, "minChecked must be >= 0"})
+	}
+	if f.MaxChecked != nil && *f.MaxChecked < 0 {
+		ps = append(ps, Problem{"maxChecked", CodeOutOfRange, "maxChecked must be >= 0"})
+	}
+	if f.MinChecked != nil && f.MaxChecked != nil && *f.MinChecked > *f.MaxChecked {
+		ps = append(ps, Problem{"maxChecked", CodeMinOverMax, "minChecked cannot exceed maxChecked"})
+	}
+	return append(ps, gradingProblems(f)...)
+}
+
+func (checkboxesType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	n, _ := arrayLen(v)
+	if f.Required && n == 0 {
+		return "Required"
+	}
+	if !present || len(f.Options) == 0 {
+		return ""
+	}
+	selected, _ := asStrings(v)
+	for _, s := range selected {
+		if !contains(f.Options, s) {
+			return "Invalid option"
+		}
+	}
+	if f.MinChecked != nil && len(selected) < *f.MinChecked {
+		return "Below min selections"
+	}
+	if f.MaxChecked != nil && len(selected) > *f.MaxChecked {
+		return "Above max selections"
+	}
+	return ""
+}
+
+func (checkboxesType) Normalize(f models.Field, v interface{}) interface{} { return v }
+
+// Count each option across arrays, and the average number selected.
+func (checkboxesType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	counts := map[string]int{}
+	for _, o := range f.Options {
+		counts[o] = 0
+	}
+	totalSelected := 0
+	for _, a := range answers {
+		n, _ := arrayLen(a.Value)
+		totalSelected += n
+		selected, _ := asStrings(a.Value)
+		for _, s := range selected {
+			if _, exists := counts[s]; exists {
+				counts[s]++
+			}
+		}
+	}
+
+	for _, o := range f.Options {
+		an.Bars = append(an.Bars, Bar{Label: o, Value: counts[o]})
+	}
+	if an.ResponseN > 0 {
+		avg := float64(totalSelected) / float64(an.ResponseN)
+		an.Average = &avg
+	}
+	an.Summary = "Checkboxes"
+}
+
+func (checkboxesType) PDFDetail(an FieldAnalytics) string {
+	if an.Average == nil {
+		return ""
+	}
+	return fmt.Sprintf("avg selected %.2f", *an.Average)
+}
+
+// GradeAnswer gives full credit for exactly the correct set. With
+// PartialCredit, other answers earn max(0, (right picks - wrong picks) /
+// correct options).
+func (checkboxesType) GradeAnswer(f models.Field, v interface{}) (float64, bool) {
+	selected, _ := asStrings(v)
+	right, wrong := 0, 0
+	picked := map[string]bool{}
+	for _, s := range selected {
+		if picked[s] {
+			continue
+		}
+		picked[s] = true
+		if contains(f.CorrectAnswers, s) {
+			right++
+		} else {
+			wrong++
+		}
+	}
+	if right == len(f.CorrectAnswers) && wrong == 0 {
+		return 1, true
+	}
+	if !f.PartialCredit || len(f.CorrectAnswers) == 0 {
+		return 0, false
+	}
+	return math.Max(0, float64(right-wrong)/float64(len(f.CorrectAnswers))), false
+}
+
+func (checkboxesType) FormatForExport(f models.Field, v interface{}) string {
+	selected, _ := asStrings(v)
+	return strings.Join(selected, "; ")
+}
+
+func (checkboxesType) Schema(f models.Field) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string", "enum": f.Options},
+	}
+	minItems := 0
+	if f.MinChecked != nil {
+		minItems = *f.MinChecked
+	}
+	if f.Required && minItems < 1 {
+		minItems = 1
+	}
+	if minItems > 0 {
+		s["minItems"] = minItems
+	}
+	if f.MaxChecked != nil {
+		s["maxItems"] = *f.MaxChecked
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/models"
+)
+
+type dropdownType struct{}
+
+func init() { Register(dropdownType{}) }
+
+func (dropdownType) Name() string { return "dropdown" }
+
+const (
+	// MaxChoices bounds dropdown option lists, including bulk imports.
+	MaxChoices = 5000
+	// maxCustomLength bounds free-text answers when allowCustom is set.
+	maxCustomLength = 200
+	defaultTopN     = 10
+)
+
+// ChoiceLabel returns the display label of a choice, defaulting to its value.
+func ChoiceLabel(c models.Choice) string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return c.Value
+}
+
+func (dropdownType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if len(f.Choices) == 0 {
+		ps = append(ps, Problem{"choices", CodeRequired, "dropdown requires choices"})
+	}
+	if len(f.Choices) > MaxChoices {
+		ps = append(ps, Problem{"choices", CodeOutOfRange, fmt.Sprintf("dropdown allows at most %d choices", MaxChoices)})
+	}
+	values := map[string]struct{}{}
+	labels := map[string]struct{}{}
+	for i, c := range f.Choices {
+		if c.Value == "" {
+			ps = append(ps, Problem{fmt.Sprintf("choices[%d].value", i), CodeRequired, "choice must have a value"})
+			continue
+		}
+		if _, dup := values[c.Value]; dup {
+			ps = append(ps, Problem{fmt.Sprintf("choices[%d].value", i), CodeDuplicate, fmt.Sprintf("duplicate choice value %q", c.Value)})
+		}
+		values[c.Value] = struct{}{}
+		label := ChoiceLabel(c)
+		if _, dup := labels[label]; dup {
+			ps = append(ps, Problem{fmt.Sprintf("choices[%d].label", i), CodeDuplicate, fmt.Sprintf("duplicate choice label %q", label)})
+		}
+		labels[label] = struct{}{}
+	}
+	if f.TopN != nil && *f.TopN < 1 {
+		ps = append(ps, Problem{"topN", CodeOutOfRange, "topN must be >= 1"})
+	}
+	return ps
+}
+
+func hasChoice(f models.Field, v string) bool {
+	for _, c := range f.Choices {
+		if c.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (dropdownType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if strings.TrimSpace(s) == "" {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	if hasChoice(f, s) {
+		return ""
+	}
+	if !f.AllowCustom {
+		return "Invalid option"
+	}
+	if len([]rune(strings.TrimSpace(s))) > maxCustomLength {
+		return "Max length exceeded"
+	}
+	return ""
+}
+
+func (dropdownType) Normalize(f models.Field, v interface{}) interface{} {
+	s, _ := v.(string)
+	if hasChoice(f, s) {
+		return s
+	}
+	return strings.TrimSpace(s)
+}
+
+// Dropdowns can have hundreds of choices, so analytics show the most picked
+// ones and roll the rest (including custom answers) into "Others".
+func (dropdownType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	labels := make(map[string]string, len(f.Choices))
+	for _, c := range f.Choices {
+		labels[c.Value] = ChoiceLabel(c)
+	}
+	counts := map[string]int{}
+	for _, a := range answers {
+		s, _ := a.Value.(string)
+		if s == "" {
+			continue
+		}
+		if l, ok := labels[s]; ok {
+			s = l
+		}
+		counts[s]++
+	}
+	n := defaultTopN
+	if f.TopN != nil {
+		n = *f.TopN
+	}
+	an.Bars = topBars(counts, n)
+	an.Summary = "Dropdown"
+}
+
+func (dropdownType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (dropdownType) Schema(f models.Field) map[string]interface{} {
+	if f.AllowCustom {
+		return map[string]interface{}{"type": "string", "maxLength": maxCustomLength}
+	}
+	values := make([]string, len(f.Choices))
+	for i, c := range f.Choices {
+		values[i] = c.Value
+	}
+	return map[string]interface{}{"type": "string", "enum": values}
+}
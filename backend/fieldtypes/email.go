@@ -0,0 +1,74 @@
+package fieldtypes
+
+import (
+	"net/mail"
+	"strings"
+
+	"backend/models"
+)
+
+type emailType struct{}
+
+func init() { Register(emailType{}) }
+
+func (emailType) Name() string { return "email" }
+
+func (emailType) ValidateDefinition(f models.Field) []Problem { return nil }
+
+// normalizeEmail accepts a bare RFC 5322 addr-spec (no display name) with a
+// dotted domain and returns it with the domain lowercased.
+func normalizeEmail(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > 254 {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return "", false
+	}
+	at := strings.LastIndexByte(s, '@')
+	domain := strings.ToLower(s[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, "[") {
+		return "", false
+	}
+	return s[:at+1] + domain, true
+}
+
+func (emailType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if strings.TrimSpace(s) == "" {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	if _, ok := normalizeEmail(s); !ok {
+		return "Invalid email"
+	}
+	return ""
+}
+
+func (emailType) Normalize(f models.Field, v interface{}) interface{} {
+	s, _ := v.(string)
+	if n, ok := normalizeEmail(s); ok {
+		return n
+	}
+	return strings.TrimSpace(s)
+}
+
+// Email answers are summarized by domain.
+func (emailType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	an.Bars = aggregateBreakdown(answers, func(s string) string {
+		if at := strings.LastIndexByte(s, '@'); at >= 0 {
+			return strings.ToLower(s[at+1:])
+		}
+		return ""
+	})
+	an.Summary = "Email domains"
+}
+
+func (emailType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (emailType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "email", "maxLength": 254}
+}
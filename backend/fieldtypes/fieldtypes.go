@@ -0,0 +1,216 @@
+// Package fieldtypes holds the behaviour of each form field type: definition
+// and answer validation, normalization, analytics and export formatting.
+// Adding a field type means adding one file here that calls Register.
+package fieldtypes
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Problem codes shared by definition validators (and package logic).
+const (
+	CodeRequired    = "required"
+	CodeInvalid     = "invalid"
+	CodeOutOfRange  = "out_of_range"
+	CodeMinOverMax  = "min_exceeds_max"
+	CodeUnknownType = "unknown_type"
+	CodeDuplicate   = "duplicate"
+	CodeUnknownRef  = "unknown_reference"
+	CodeCycle       = "cycle"
+)
+
+// Problem is one issue with a field definition. Path is relative to the
+// field, e.g. "maxLength" or "options[2]".
+type Problem struct {
+	Path    string
+	Code    string
+	Message string
+}
+
+// Answer is one stored answer to a field, with the time it was submitted.
+type Answer struct {
+	Value       interface{}
+	SubmittedAt time.Time
+}
+
+type Bar struct {
+	Label string `json:"label"`
+	Value int    `json:"value"`
+}
+
+// FieldAnalytics is the per-field analytics payload returned to the frontend.
+// analytics.Compute fills the identity and ResponseN; FieldType.Aggregate
+// fills the rest.
+type FieldAnalytics struct {
+	FieldID   string   `json:"fieldId"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Summary   string   `json:"summary"`
+	Bars      []Bar    `json:"bars"`
+	Average   *float64 `json:"average,omitempty"` // rating avg or avg selected for checkboxes
+	Scale     *int     `json:"scale,omitempty"`   // rating scale (for ratings)
+	ResponseN int      `json:"responseN"`
+
+	Stats    *NumericStats `json:"stats,omitempty"`    // number
+	Bucket   string        `json:"bucket,omitempty"`   // date/time: day | week | month | year | hour
+	Weekdays []Bar         `json:"weekdays,omitempty"` // date/datetime: Mon..Sun
+
+	Rows    []RowBreakdown `json:"rows,omitempty"`    // matrix: one stacked bar per row
+	Ranking []RankStat     `json:"ranking,omitempty"` // ranking: per option, best Borda score first
+	NPS     *NPSSummary    `json:"nps,omitempty"`
+}
+
+// RowBreakdown is the column distribution of one matrix row.
+type RowBreakdown struct {
+	RowID     string `json:"rowId"`
+	Label     string `json:"label"`
+	Bars      []Bar  `json:"bars"`
+	ResponseN int    `json:"responseN"`
+}
+
+// RankStat summarizes how one option was ranked. AverageRank is 1-based and
+// only counts answers that ranked the option.
+type RankStat struct {
+	Option      string   `json:"option"`
+	AverageRank *float64 `json:"averageRank,omitempty"`
+	FirstChoice int      `json:"firstChoice"`
+	Borda       int      `json:"borda"`
+}
+
+// NPSSummary splits 0-10 answers into detractors (0-6), passives (7-8) and
+// promoters (9-10). Score is %promoters - %detractors, from -100 to 100.
+// Trend is bucketed by submission time; FieldAnalytics.Bucket names the unit.
+type NPSSummary struct {
+	Detractors int        `json:"detractors"`
+	Passives   int        `json:"passives"`
+	Promoters  int        `json:"promoters"`
+	Score      float64    `json:"score"`
+	Trend      []NPSPoint `json:"trend"`
+}
+
+type NPSPoint struct {
+	Label string   `json:"label"`
+	Score *float64 `json:"score"` // null for periods without answers
+	N     int      `json:"n"`
+}
+
+type NumericStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"stdDev"` // sample standard deviation
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Unit   string  `json:"unit,omitempty"`
+}
+
+type FieldType interface {
+	// Name is the models.Field.Type value this implementation handles.
+	Name() string
+	// ValidateDefinition checks the type-specific properties of a field.
+	ValidateDefinition(f models.Field) []Problem
+	// ValidateAnswer checks a submitted value, including the required rule.
+	// present is false when the answers object has no key for the field.
+	// It returns a short message, or "" if the value is acceptable.
+	ValidateAnswer(f models.Field, v interface{}, present bool) string
+	// Normalize returns the value to store for an accepted answer.
+	Normalize(f models.Field, v interface{}) interface{}
+	// Aggregate fills the type-specific parts of an from the field's answers.
+	Aggregate(f models.Field, answers []Answer, an *FieldAnalytics)
+	// FormatForExport renders a stored answer as one CSV cell.
+	FormatForExport(f models.Field, v interface{}) string
+	// Schema returns the JSON Schema (draft 2020-12) for the field's answer.
+	Schema(f models.Field) map[string]interface{}
+}
+
+// PDFDetailer is implemented by types that append figures (e.g. an average)
+// to the summary line of their section in the PDF export.
+type PDFDetailer interface {
+	PDFDetail(an FieldAnalytics) string
+}
+
+// MultiColumnExporter is implemented by types whose answers span several CSV
+// columns. ExportColumns names them (appended to the field label) and
+// ExportCells renders one value per column, "" for a missing answer.
+type MultiColumnExporter interface {
+	ExportColumns(f models.Field) []string
+	ExportCells(f models.Field, v interface{}) []string
+}
+
+// Grader is implemented by types that can be scored in quiz mode against
+// f.CorrectAnswers. GradeAnswer returns the share of the question's points a
+// stored answer earns, from 0 to 1, and whether it is fully correct.
+type Grader interface {
+	GradeAnswer(f models.Field, v interface{}) (credit float64, correct bool)
+}
+
+// Computed is implemented by types whose value the server computes from
+// other answers. Expression returns the field's expression (see
+// logic.ParseExpr); values submitted for such fields are replaced.
+type Computed interface {
+	Expression(f models.Field) string
+}
+
+// LinkExporter is implemented by types whose answers refer to resources the
+// API serves. ExportLinks renders the CSV cell in place of FormatForExport,
+// with absolute URLs under apiBase (e.g. "https://host/api").
+type LinkExporter interface {
+	ExportLinks(f models.Field, v interface{}, apiBase string) string
+}
+
+// PDFAnswerRenderer is implemented by types that draw an answer in the
+// per-response PDF instead of printing FormatForExport. It draws at the
+// cursor and leaves the cursor below what it drew.
+type PDFAnswerRenderer interface {
+	RenderPDFAnswer(pdf *gofpdf.Fpdf, f models.Field, v interface{}) error
+}
+
+// PDFSectionRenderer is implemented by types whose section in the PDF export
+// needs more than the option/count/percent table drawn by PDFBarTable.
+type PDFSectionRenderer interface {
+	RenderPDFSection(pdf *gofpdf.Fpdf, an FieldAnalytics)
+}
+
+var registry = map[string]FieldType{}
+
+// Register makes t available under t.Name(). It is meant to be called from
+// init functions and panics on duplicate names.
+func Register(t FieldType) {
+	if _, dup := registry[t.Name()]; dup {
+		panic(fmt.Sprintf("fieldtypes: %q registered twice", t.Name()))
+	}
+	registry[t.Name()] = t
+}
+
+// Lookup returns the implementation for a field type name.
+func Lookup(name string) (FieldType, bool) {
+	t, ok := registry[name]
+	return t, ok
+}
+
+// ExpressionOf returns the expression of a field whose type is Computed; ok
+// is false for every other field.
+func ExpressionOf(f models.Field) (expr string, ok bool) {
+	t, _ := Lookup(f.Type)
+	c, ok := t.(Computed)
+	if !ok {
+		return "", false
+	}
+	return c.Expression(f), true
+}
+
+// Names lists the registered field type names in sorted order.
+func Names() []string {
+	out := make([]string, 0, len(registry))
+	for n := range registry {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,162 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
+
+type fileType struct{}
+
+func init() { Register(fileType{}) }
+
+func (fileType) Name() string { return "file" }
+
+const (
+	// MaxFileSize bounds maxFileSize and the upload endpoint's request body.
+	MaxFileSize     = 25 << 20
+	maxFilesLimit   = 20
+	defaultMaxFiles = 1
+)
+
+// FileSizeLimit is the largest upload the field accepts.
+func FileSizeLimit(f models.Field) int64 {
+	if f.MaxFileSize != nil && *f.MaxFileSize < MaxFileSize {
+		return *f.MaxFileSize
+	}
+	return MaxFileSize
+}
+
+func maxFiles(f models.Field) int {
+	if f.MaxFiles != nil {
+		return *f.MaxFiles
+	}
+	return defaultMaxFiles
+}
+
+// AllowsType reports whether the field accepts a file of the given MIME
+// type. Parameters such as "; charset=utf-8" are ignored.
+func AllowsType(f models.Field, contentType string) bool {
+	if len(f.AllowedTypes) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range f.AllowedTypes {
+		a = strings.ToLower(a)
+		if a == mt || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileIDs returns the upload ids listed in a file answer.
+func FileIDs(v interface{}) []string {
+	ids, _ := asStrings(v)
+	return ids
+}
+
+func (fileType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	for i, a := range f.AllowedTypes {
+		if mt, _, err := mime.ParseMediaType(a); err != nil || !strings.Contains(mt, "/") {
+			ps = append(ps, Problem{fmt.Sprintf("allowedTypes[%d]", i), CodeInvalid, fmt.Sprintf("%q is not a MIME type", a)})
+		}
+	}
+	if f.MaxFileSize != nil && (*f.MaxFileSize < 1 || *f.MaxFileSize > MaxFileSize) {
+		ps = append(ps, Problem{"maxFileSize", CodeOutOfRange, fmt.Sprintf("maxFileSize must be between 1 and %d bytes", MaxFileSize)})
+	}
+	if f.MaxFiles != nil && (*f.MaxFiles < 1 || *f.MaxFiles > maxFilesLimit) {
+		ps = append(ps, Problem{"maxFiles", CodeOutOfRange, fmt.Sprintf("maxFiles must be between 1 and %d", maxFilesLimit)})
+	}
+	return ps
+}
+
+// ValidateAnswer checks the shape of the id list; the API checks that each
+// id is an upload made for this field.
+func (fileType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	n, _ := arrayLen(v)
+	if n == 0 {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	ids, ok := asStrings(v)
+	if !ok || len(ids) != n {
+		return "Must be a list of upload ids"
+	}
+	if n > maxFiles(f) {
+		return fmt.Sprintf("At most %d files", maxFiles(f))
+	}
+	seen := map[string]struct{}{}
+	for _, id := range ids {
+		if _, dup := seen[id]; dup || id == "" {
+			return "Invalid upload id"
+		}
+		seen[id] = struct{}{}
+	}
+	return ""
+}
+
+func (fileType) Normalize(f models.Field, v interface{}) interface{} {
+	ids, _ := asStrings(v)
+	return ids
+}
+
+// Count answers by number of files attached.
+func (fileType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	top := maxFiles(f)
+	counts := make([]int, top+1)
+	total := 0
+	for _, a := range answers {
+		n, _ := arrayLen(a.Value)
+		total += n
+		if n > top {
+			n = top
+		}
+		counts[n]++
+	}
+	for n := 1; n <= top; n++ {
+		label := strconv.Itoa(n) + " files"
+		if n == 1 {
+			label = "1 file"
+		}
+		an.Bars = append(an.Bars, Bar{Label: label, Value: counts[n]})
+	}
+	an.Summary = fmt.Sprintf("File upload · %d files", total)
+}
+
+func (fileType) FormatForExport(f models.Field, v interface{}) string {
+	ids, _ := asStrings(v)
+	return strings.Join(ids, "; ")
+}
+
+// ExportLinks renders the answer as download URLs, space-separated.
+func (fileType) ExportLinks(f models.Field, v interface{}, apiBase string) string {
+	ids := FileIDs(v)
+	links := make([]string, len(ids))
+	for i, id := range ids {
+		links[i] = apiBase + "/uploads/" + id
+	}
+	return strings.Join(links, " ")
+}
+
+func (fileType) Schema(f models.Field) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"uniqueItems": true,
+		"maxItems":    maxFiles(f),
+	}
+	if f.Required {
+		s["minItems"] = 1
+	}
+	return s
+}
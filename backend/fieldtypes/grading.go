@@ -0,0 +1,28 @@
+package fieldtypes
+
+import (
+	"fmt"
+
+	"backend/models"
+)
+
+// gradingProblems checks the quiz properties shared by the choice types:
+// correct answers must be distinct options and points must not be negative.
+func gradingProblems(f models.Field) []Problem {
+	var ps []Problem
+	seen := map[string]bool{}
+	for i, c := range f.CorrectAnswers {
+		path := fmt.Sprintf("correctAnswers[%d]", i)
+		switch {
+		case !contains(f.Options, c):
+			ps = append(ps, Problem{path, CodeInvalid, fmt.Sprintf("%q is not one of the options", c)})
+		case seen[c]:
+			ps = append(ps, Problem{path, CodeDuplicate, fmt.Sprintf("duplicate correct answer %q", c)})
+		}
+		seen[c] = true
+	}
+	if f.Points != nil && *f.Points < 0 {
+		ps = append(ps, Problem{"points", CodeOutOfRange, "points must be >= 0"})
+	}
+	return ps
+}
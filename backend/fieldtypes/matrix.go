@@ -0,0 +1,269 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type matrixType struct{}
+
+func init() { Register(matrixType{}) }
+
+func (matrixType) Name() string { return "matrix" }
+
+func (matrixType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if len(f.Rows) == 0 {
+		ps = append(ps, Problem{"rows", CodeRequired, "matrix requires rows"})
+	}
+	if len(f.Columns) == 0 {
+		ps = append(ps, Problem{"columns", CodeRequired, "matrix requires columns"})
+	}
+	ids := map[string]struct{}{}
+	for i, r := range f.Rows {
+		if strings.TrimSpace(r.ID) == "" {
+			ps = append(ps, Problem{fmt.Sprintf("rows[%d].id", i), CodeRequired, "row must have an id"})
+		} else if _, dup := ids[r.ID]; dup {
+			ps = append(ps, Problem{fmt.Sprintf("rows[%d].id", i), CodeDuplicate, fmt.Sprintf("duplicate row id %q", r.ID)})
+		}
+		ids[r.ID] = struct{}{}
+		if strings.TrimSpace(r.Label) == "" {
+			ps = append(ps, Problem{fmt.Sprintf("rows[%d].label", i), CodeRequired, "row must have a label"})
+		}
+	}
+	cols := map[string]struct{}{}
+	for i, col := range f.Columns {
+		if strings.TrimSpace(col) == "" {
+			ps = append(ps, Problem{fmt.Sprintf("columns[%d]", i), CodeRequired, "column must not be empty"})
+			continue
+		}
+		if _, dup := cols[col]; dup {
+			ps = append(ps, Problem{fmt.Sprintf("columns[%d]", i), CodeDuplicate, fmt.Sprintf("duplicate column %q", col)})
+		}
+		cols[col] = struct{}{}
+	}
+	return ps
+}
+
+// rowValues returns the columns picked in one row of an answer.
+func rowValues(f models.Field, v interface{}) []string {
+	if f.MultiSelect {
+		vals, _ := asStrings(v)
+		return vals
+	}
+	if s, ok := v.(string); ok && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func (matrixType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	obj, ok := asObject(v)
+	if !ok && v != nil {
+		return "Must be an object of row answers"
+	}
+	rows := make(map[string]struct{}, len(f.Rows))
+	for _, r := range f.Rows {
+		rows[r.ID] = struct{}{}
+	}
+	for id, rv := range obj {
+		if _, ok := rows[id]; !ok {
+			return fmt.Sprintf("Unknown row %q", id)
+		}
+		if rv == nil {
+			continue
+		}
+		if f.MultiSelect {
+			if _, ok := asStrings(rv); !ok {
+				return "Rows must be arrays of columns"
+			}
+		} else if _, ok := rv.(string); !ok {
+			return "Rows must be a single column"
+		}
+		seen := map[string]bool{}
+		for _, col := range rowValues(f, rv) {
+			if !contains(f.Columns, col) {
+				return "Invalid option"
+			}
+			if seen[col] {
+				return "Duplicate column in a row"
+			}
+			seen[col] = true
+		}
+	}
+	for _, r := range f.Rows {
+		if (f.Required || r.Required) && len(rowValues(f, obj[r.ID])) == 0 {
+			return fmt.Sprintf("Row %q is required", r.Label)
+		}
+	}
+	return ""
+}
+
+// Normalize drops unanswered rows.
+func (matrixType) Normalize(f models.Field, v interface{}) interface{} {
+	obj, _ := asObject(v)
+	out := map[string]interface{}{}
+	for _, r := range f.Rows {
+		vals := rowValues(f, obj[r.ID])
+		if len(vals) == 0 {
+			continue
+		}
+		if f.MultiSelect {
+			out[r.ID] = vals
+		} else {
+			out[r.ID] = vals[0]
+		}
+	}
+	return out
+}
+
+// Each row gets its own column distribution (a stacked bar in the UI); Bars
+// holds the column totals across all rows.
+func (matrixType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	totals := make(map[string]int, len(f.Columns))
+	for _, r := range f.Rows {
+		counts := make(map[string]int, len(f.Columns))
+		n := 0
+		for _, a := range answers {
+			obj, _ := asObject(a.Value)
+			vals := rowValues(f, obj[r.ID])
+			if len(vals) > 0 {
+				n++
+			}
+			for _, col := range vals {
+				if contains(f.Columns, col) {
+					counts[col]++
+					totals[col]++
+				}
+			}
+		}
+		rb := RowBreakdown{RowID: r.ID, Label: r.Label, ResponseN: n}
+		for _, col := range f.Columns {
+			rb.Bars = append(rb.Bars, Bar{Label: col, Value: counts[col]})
+		}
+		an.Rows = append(an.Rows, rb)
+	}
+	for _, col := range f.Columns {
+		an.Bars = append(an.Bars, Bar{Label: col, Value: totals[col]})
+	}
+	an.Summary = "Matrix"
+}
+
+func (matrixType) PDFDetail(an FieldAnalytics) string {
+	parts := make([]string, 0, len(an.Rows))
+	for _, r := range an.Rows {
+		best, top := "", 0
+		for _, b := range r.Bars {
+			if b.Value > top {
+				best, top = b.Label, b.Value
+			}
+		}
+		if top > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", r.Label, best))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "most common " + strings.Join(parts, "; ")
+}
+
+// RenderPDFSection draws the breakdown as a grid: one line per row, one
+// count per column.
+func (matrixType) RenderPDFSection(pdf *gofpdf.Fpdf, an FieldAnalytics) {
+	if len(an.Rows) == 0 {
+		PDFBarTable(pdf, an)
+		return
+	}
+	cols := len(an.Rows[0].Bars)
+	if cols < 1 {
+		cols = 1
+	}
+	w := 120.0 / float64(cols)
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.CellFormat(60, 6, "Row", "1", 0, "", true, 0, "")
+	for _, b := range an.Rows[0].Bars {
+		pdf.CellFormat(w, 6, b.Label, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for _, r := range an.Rows {
+		pdf.CellFormat(60, 6, r.Label, "1", 0, "", false, 0, "")
+		for _, b := range r.Bars {
+			pdf.CellFormat(w, 6, fmt.Sprintf("%d", b.Value), "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func (matrixType) FormatForExport(f models.Field, v interface{}) string {
+	cells := matrixType{}.ExportCells(f, v)
+	parts := make([]string, 0, len(cells))
+	for i, r := range f.Rows {
+		if cells[i] != "" {
+			parts = append(parts, r.Label+": "+cells[i])
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (matrixType) ExportColumns(f models.Field) []string {
+	out := make([]string, len(f.Rows))
+	for i, r := range f.Rows {
+		out[i] = r.Label
+	}
+	return out
+}
+
+func (matrixType) ExportCells(f models.Field, v interface{}) []string {
+	obj, _ := asObject(v)
+	out := make([]string, len(f.Rows))
+	for i, r := range f.Rows {
+		out[i] = strings.Join(rowValues(f, obj[r.ID]), "; ")
+	}
+	return out
+}
+
+func (matrixType) Schema(f models.Field) map[string]interface{} {
+	var cell map[string]interface{}
+	if f.MultiSelect {
+		cell = map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string", "enum": f.Columns},
+			"uniqueItems": true,
+		}
+	} else {
+		cell = map[string]interface{}{"type": "string", "enum": f.Columns}
+	}
+	props := make(map[string]interface{}, len(f.Rows))
+	required := []string{}
+	for _, r := range f.Rows {
+		rs := map[string]interface{}{"title": r.Label}
+		for k, v := range cell {
+			rs[k] = v
+		}
+		if f.Required || r.Required {
+			required = append(required, r.ID)
+			if f.MultiSelect {
+				rs["minItems"] = 1
+			}
+		}
+		props[r.ID] = rs
+	}
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
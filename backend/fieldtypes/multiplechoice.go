@@ -0,0 +1,78 @@
+package fieldtypes
+
+import "backend/models"
+
+type multipleChoiceType struct{}
+
+func init() { Register(multipleChoiceType{}) }
+
+func (multipleChoiceType) Name() string { return "multipleChoice" }
+
+func (multipleChoiceType) ValidateDefinition(f models.Field) []Problem {
+	if len(f.Options) == 0 {
+		return []Problem{{"options", CodeRequired, "multipleChoice requires options"}}
+	}
+	ps := gradingProblems(f)
+	if f.PartialCredit {
+		ps = append(ps, Problem{"partialCredit", CodeInvalid, "partialCredit only applies to checkboxes"})
+	}
+	return ps
+}
+
+func (multipleChoiceType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if f.Required && s == "" {
+		return "Required"
+	}
+	if !present || len(f.Options) == 0 {
+		return ""
+	}
+	if !contains(f.Options, s) {
+		return "Invalid option"
+	}
+	return ""
+}
+
+func (multipleChoiceType) Normalize(f models.Field, v interface{}) interface{} { return v }
+
+// Count each selected option; bucket unknowns (e.g. renamed options) into "Other".
+func (multipleChoiceType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	counts := map[string]int{}
+	for _, o := range f.Options {
+		counts[o] = 0
+	}
+	other := 0
+	for _, a := range answers {
+		if s, ok := a.Value.(string); ok {
+			if _, exists := counts[s]; exists {
+				counts[s]++
+			} else {
+				other++
+			}
+		}
+	}
+	for _, o := range f.Options {
+		an.Bars = append(an.Bars, Bar{Label: o, Value: counts[o]})
+	}
+	if other > 0 {
+		an.Bars = append(an.Bars, Bar{Label: "Other", Value: other})
+	}
+	an.Summary = "Multiple choice"
+}
+
+// GradeAnswer gives full credit for any of the correct answers.
+func (multipleChoiceType) GradeAnswer(f models.Field, v interface{}) (float64, bool) {
+	s, _ := v.(string)
+	if contains(f.CorrectAnswers, s) {
+		return 1, true
+	}
+	return 0, false
+}
+
+func (multipleChoiceType) FormatForExport(f models.Field, v interface{}) string {
+	return FormatValue(v)
+}
+
+func (multipleChoiceType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": f.Options}
+}
@@ -0,0 +1,185 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type npsType struct{}
+
+func init() { Register(npsType{}) }
+
+func (npsType) Name() string { return "nps" }
+
+func (npsType) ValidateDefinition(f models.Field) []Problem { return nil }
+
+func (npsType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	if !present || isBlank(v) {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	num, ok := asFloat(v)
+	if !ok || num != math.Trunc(num) {
+		return "Must be a whole number"
+	}
+	if num < 0 || num > 10 {
+		return "Out of range"
+	}
+	return ""
+}
+
+func (npsType) Normalize(f models.Field, v interface{}) interface{} {
+	if num, ok := asFloat(v); ok {
+		return int(num)
+	}
+	return nil // blank
+}
+
+// npsTally counts one group of answers.
+type npsTally struct{ detractors, passives, promoters int }
+
+func (t *npsTally) add(score int) {
+	switch {
+	case score <= 6:
+		t.detractors++
+	case score <= 8:
+		t.passives++
+	default:
+		t.promoters++
+	}
+}
+
+func (t npsTally) n() int { return t.detractors + t.passives + t.promoters }
+
+func (t npsTally) score() float64 {
+	return float64(t.promoters-t.detractors) / float64(t.n()) * 100
+}
+
+func (npsType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	counts := make([]int, 11)
+	var total npsTally
+	var times []time.Time
+	var scores []int
+	for _, a := range answers {
+		v, ok := asFloat(a.Value)
+		if !ok || v < 0 || v > 10 {
+			continue
+		}
+		s := int(v)
+		counts[s]++
+		total.add(s)
+		times = append(times, a.SubmittedAt)
+		scores = append(scores, s)
+	}
+	for i, c := range counts {
+		an.Bars = append(an.Bars, Bar{Label: strconv.Itoa(i), Value: c})
+	}
+	an.Summary = "Net Promoter Score"
+	if total.n() == 0 {
+		return
+	}
+
+	sum := &NPSSummary{
+		Detractors: total.detractors,
+		Passives:   total.passives,
+		Promoters:  total.promoters,
+		Score:      total.score(),
+	}
+	tb := newTimeBuckets(timeSpan(times))
+	byBucket := map[int64]*npsTally{}
+	for i, d := range times {
+		k := tb.start(d).Unix()
+		if byBucket[k] == nil {
+			byBucket[k] = &npsTally{}
+		}
+		byBucket[k].add(scores[i])
+	}
+	for _, b := range tb.starts(times) {
+		p := NPSPoint{Label: tb.label(b)}
+		if t := byBucket[b.Unix()]; t != nil {
+			s := t.score()
+			p.Score, p.N = &s, t.n()
+		}
+		sum.Trend = append(sum.Trend, p)
+	}
+	an.Bucket = tb.kind
+	an.NPS = sum
+}
+
+func (npsType) PDFDetail(an FieldAnalytics) string {
+	if an.NPS == nil {
+		return ""
+	}
+	return fmt.Sprintf("NPS %+.0f · %d promoters, %d passives, %d detractors",
+		an.NPS.Score, an.NPS.Promoters, an.NPS.Passives, an.NPS.Detractors)
+}
+
+// RenderPDFSection draws the score on a -100..100 band (red below 0, amber
+// to 50, green above), the score per period, then the 0-10 distribution.
+func (npsType) RenderPDFSection(pdf *gofpdf.Fpdf, an FieldAnalytics) {
+	if an.NPS != nil {
+		pdfNPSGauge(pdf, *an.NPS)
+	}
+	PDFBarTable(pdf, an)
+}
+
+func pdfNPSGauge(pdf *gofpdf.Fpdf, nps NPSSummary) {
+	const width = 170.0
+	x, y := pdf.GetX(), pdf.GetY()
+	at := func(score float64) float64 { return x + (score+100)/200*width }
+
+	pdf.SetFillColor(220, 80, 70)
+	pdf.Rect(at(-100), y, at(0)-at(-100), 6, "F")
+	pdf.SetFillColor(240, 190, 60)
+	pdf.Rect(at(0), y, at(50)-at(0), 6, "F")
+	pdf.SetFillColor(90, 180, 100)
+	pdf.Rect(at(50), y, at(100)-at(50), 6, "F")
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(at(nps.Score), y-1.5, at(nps.Score), y+7.5)
+	pdf.SetLineWidth(0.2)
+
+	pdf.SetXY(x, y+8)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(width/2, 4, "-100", "", 0, "", false, 0, "")
+	pdf.CellFormat(width/2, 4, "100", "", 0, "R", false, 0, "")
+	pdf.Ln(5)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.Cell(0, 5, fmt.Sprintf("NPS %+.0f", nps.Score))
+	pdf.Ln(7)
+
+	if len(nps.Trend) > 1 {
+		pdf.SetFillColor(240, 240, 240)
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(70, 6, "Period", "1", 0, "", true, 0, "")
+		pdf.CellFormat(30, 6, "NPS", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(30, 6, "Answers", "1", 0, "R", true, 0, "")
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+		for _, p := range nps.Trend {
+			score := "-"
+			if p.Score != nil {
+				score = fmt.Sprintf("%+.0f", *p.Score)
+			}
+			pdf.CellFormat(70, 6, p.Label, "1", 0, "", false, 0, "")
+			pdf.CellFormat(30, 6, score, "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 6, fmt.Sprintf("%d", p.N), "1", 0, "R", false, 0, "")
+			pdf.Ln(-1)
+		}
+		pdf.Ln(3)
+	}
+}
+
+func (npsType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (npsType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 10}
+}
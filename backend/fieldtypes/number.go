@@ -0,0 +1,132 @@
+package fieldtypes
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
+
+type numberType struct{}
+
+func init() { Register(numberType{}) }
+
+func (numberType) Name() string { return "number" }
+
+func (numberType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if f.MinValue != nil && f.MaxValue != nil && *f.MinValue > *f.MaxValue {
+		ps = append(ps, Problem{"maxValue", CodeMinOverMax, "minValue cannot exceed maxValue"})
+	}
+	if f.Step != nil && *f.Step <= 0 {
+		ps = append(ps, Problem{"step", CodeOutOfRange, "step must be > 0"})
+	}
+	if f.IntegerOnly && f.Step != nil && *f.Step != math.Trunc(*f.Step) {
+		ps = append(ps, Problem{"step", CodeInvalid, "step must be a whole number when integerOnly is set"})
+	}
+	return ps
+}
+
+// parseNumber accepts JSON numbers and numeric strings (as sent by HTML inputs).
+func parseNumber(v interface{}) (float64, bool) {
+	if n, ok := asFloat(v); ok {
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+func isBlank(v interface{}) bool {
+	s, isString := v.(string)
+	return v == nil || isString && strings.TrimSpace(s) == ""
+}
+
+func (numberType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	if !present || isBlank(v) {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	n, ok := parseNumber(v)
+	if !ok {
+		return "Invalid number"
+	}
+	if f.IntegerOnly && n != math.Trunc(n) {
+		return "Must be a whole number"
+	}
+	if f.MinValue != nil && n < *f.MinValue {
+		return "Below minimum"
+	}
+	if f.MaxValue != nil && n > *f.MaxValue {
+		return "Above maximum"
+	}
+	if f.Step != nil {
+		base := 0.0
+		if f.MinValue != nil {
+			base = *f.MinValue
+		}
+		q := (n - base) / *f.Step
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			return "Not a valid step"
+		}
+	}
+	return ""
+}
+
+func (numberType) Normalize(f models.Field, v interface{}) interface{} {
+	if n, ok := parseNumber(v); ok {
+		return n
+	}
+	return nil
+}
+
+func (numberType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	vals := make([]float64, 0, len(answers))
+	for _, a := range answers {
+		if n, ok := parseNumber(a.Value); ok {
+			vals = append(vals, n)
+		}
+	}
+	an.Stats = numericStats(vals)
+	if an.Stats != nil {
+		an.Average = &an.Stats.Mean
+		if f.Unit != nil {
+			an.Stats.Unit = *f.Unit
+		}
+	}
+	an.Bars = histogram(vals, f.IntegerOnly)
+	an.Summary = "Number"
+}
+
+func (numberType) PDFDetail(an FieldAnalytics) string { return formatStats(an.Stats) }
+
+func (numberType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (numberType) Schema(f models.Field) map[string]interface{} {
+	s := map[string]interface{}{"type": "number"}
+	if f.IntegerOnly {
+		s["type"] = "integer"
+	}
+	if f.MinValue != nil {
+		s["minimum"] = *f.MinValue
+	}
+	if f.MaxValue != nil {
+		s["maximum"] = *f.MaxValue
+	}
+	if f.Step != nil && f.MinValue == nil {
+		s["multipleOf"] = *f.Step
+	}
+	if f.Unit != nil {
+		s["description"] = "in " + *f.Unit
+	}
+	return s
+}
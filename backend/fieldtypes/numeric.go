@@ -0,0 +1,105 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// numericStats summarizes vals; it returns nil when there are none.
+func numericStats(vals []float64) *NumericStats {
+	if len(vals) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	n := len(sorted)
+	st := &NumericStats{
+		Count: n,
+		Mean:  sum / float64(n),
+		Min:   sorted[0],
+		Max:   sorted[n-1],
+	}
+	if n%2 == 1 {
+		st.Median = sorted[n/2]
+	} else {
+		st.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	if n > 1 {
+		ss := 0.0
+		for _, v := range sorted {
+			ss += (v - st.Mean) * (v - st.Mean)
+		}
+		st.StdDev = math.Sqrt(ss / float64(n-1))
+	}
+	return st
+}
+
+// maxHistogramBins caps automatic binning so charts stay readable.
+const maxHistogramBins = 20
+
+// histogram bins vals into equal-width buckets. Integer data spanning at most
+// maxHistogramBins values gets one bar per value; otherwise the bin count
+// follows Sturges' rule.
+func histogram(vals []float64, integers bool) []Bar {
+	if len(vals) == 0 {
+		return []Bar{}
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	if integers && hi-lo+1 <= maxHistogramBins {
+		counts := make([]int, int(hi-lo)+1)
+		for _, v := range vals {
+			counts[int(v-lo)]++
+		}
+		bars := make([]Bar, len(counts))
+		for i, c := range counts {
+			bars[i] = Bar{Label: fmt.Sprintf("%g", lo+float64(i)), Value: c}
+		}
+		return bars
+	}
+	if hi == lo {
+		return []Bar{{Label: fmt.Sprintf("%g", lo), Value: len(vals)}}
+	}
+
+	k := int(math.Ceil(math.Log2(float64(len(vals))))) + 1
+	if k > maxHistogramBins {
+		k = maxHistogramBins
+	}
+	width := (hi - lo) / float64(k)
+	counts := make([]int, k)
+	for _, v := range vals {
+		i := int((v - lo) / width)
+		if i >= k {
+			i = k - 1 // the top edge belongs to the last bin
+		}
+		counts[i]++
+	}
+	bars := make([]Bar, k)
+	for i, c := range counts {
+		from := lo + float64(i)*width
+		bars[i] = Bar{Label: fmt.Sprintf("%.4g–%.4g", from, from+width), Value: c}
+	}
+	return bars
+}
+
+func formatStats(st *NumericStats) string {
+	if st == nil {
+		return ""
+	}
+	unit := ""
+	if st.Unit != "" {
+		unit = " " + st.Unit
+	}
+	return fmt.Sprintf("mean %.4g%s · median %.4g · sd %.4g · min %.4g · max %.4g",
+		st.Mean, unit, st.Median, st.StdDev, st.Min, st.Max)
+}
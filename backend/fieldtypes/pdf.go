@@ -0,0 +1,35 @@
+package fieldtypes
+
+import (
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDFBarTable draws the default PDF section for a field: one line per bar
+// with its count and share of the field's responses.
+func PDFBarTable(pdf *gofpdf.Fpdf, an FieldAnalytics) {
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(110, 6, "Option", "1", 0, "", true, 0, "")
+	pdf.CellFormat(30, 6, "Count", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(30, 6, "Percent", "1", 0, "R", true, 0, "")
+	pdf.Ln(-1)
+
+	total := float64(an.ResponseN)
+	if total < 1 {
+		total = 1
+	}
+	pdf.SetFont("Helvetica", "", 9)
+	for _, b := range an.Bars {
+		pct := (float64(b.Value) / total) * 100.0
+		label := b.Label
+		if label == "" {
+			label = "-"
+		}
+		pdf.CellFormat(110, 6, label, "1", 0, "", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%d", b.Value), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%.0f%%", pct), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+}
@@ -0,0 +1,122 @@
+package fieldtypes
+
+import (
+	"regexp"
+	"strings"
+
+	"backend/models"
+)
+
+type phoneType struct{}
+
+func init() { Register(phoneType{}) }
+
+func (phoneType) Name() string { return "phone" }
+
+var (
+	reE164        = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	reCountryCode = regexp.MustCompile(`^\+?[1-9][0-9]{0,2}$`)
+	rePhoneInput  = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+)
+
+func (phoneType) ValidateDefinition(f models.Field) []Problem {
+	if f.DefaultCountryCode != nil && !reCountryCode.MatchString(*f.DefaultCountryCode) {
+		return []Problem{{"defaultCountryCode", CodeInvalid, "defaultCountryCode must be a calling code like \"+44\""}}
+	}
+	return nil
+}
+
+// normalizePhone returns the E.164 form of s. Spaces, dots, dashes and
+// parentheses are ignored; a leading 00 is read as +. Numbers without an
+// international prefix use the field's defaultCountryCode, dropping a
+// national trunk 0.
+func normalizePhone(f models.Field, s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !rePhoneInput.MatchString(s) {
+		return "", false
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r == '+' {
+			return r
+		}
+		return -1
+	}, s)
+	switch {
+	case strings.HasPrefix(digits, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = "+" + digits[2:]
+	case f.DefaultCountryCode != nil:
+		cc := strings.TrimPrefix(*f.DefaultCountryCode, "+")
+		digits = "+" + cc + strings.TrimPrefix(digits, "0")
+	default:
+		return "", false
+	}
+	if !reE164.MatchString(digits) {
+		return "", false
+	}
+	return digits, true
+}
+
+func (phoneType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if strings.TrimSpace(s) == "" {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	if _, ok := normalizePhone(f, s); !ok {
+		return "Invalid phone number"
+	}
+	return ""
+}
+
+func (phoneType) Normalize(f models.Field, v interface{}) interface{} {
+	s, _ := v.(string)
+	if n, ok := normalizePhone(f, s); ok {
+		return n
+	}
+	return strings.TrimSpace(s)
+}
+
+// Country calling codes that are one or two digits long; all others are three.
+var (
+	callingCodes1 = "17"
+	callingCodes2 = map[string]bool{
+		"20": true, "27": true, "30": true, "31": true, "32": true, "33": true, "34": true,
+		"36": true, "39": true, "40": true, "41": true, "43": true, "44": true, "45": true,
+		"46": true, "47": true, "48": true, "49": true, "51": true, "52": true, "53": true,
+		"54": true, "55": true, "56": true, "57": true, "58": true, "60": true, "61": true,
+		"62": true, "63": true, "64": true, "65": true, "66": true, "81": true, "82": true,
+		"84": true, "86": true, "90": true, "91": true, "92": true, "93": true, "94": true,
+		"95": true, "98": true,
+	}
+)
+
+// callingCode returns the "+CC" prefix of an E.164 number.
+func callingCode(e164 string) string {
+	if !reE164.MatchString(e164) {
+		return ""
+	}
+	d := e164[1:]
+	switch {
+	case strings.ContainsRune(callingCodes1, rune(d[0])):
+		return "+" + d[:1]
+	case callingCodes2[d[:2]]:
+		return "+" + d[:2]
+	default:
+		return "+" + d[:3]
+	}
+}
+
+// Phone answers are summarized by country calling code.
+func (phoneType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	an.Bars = aggregateBreakdown(answers, callingCode)
+	an.Summary = "Phone country codes"
+}
+
+func (phoneType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (phoneType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "pattern": rePhoneInput.String()}
+}
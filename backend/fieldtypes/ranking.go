@@ -0,0 +1,166 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type rankingType struct{}
+
+func init() { Register(rankingType{}) }
+
+func (rankingType) Name() string { return "ranking" }
+
+// rankLength is how many options an answer must order: K, or all of them.
+func rankLength(f models.Field) int {
+	if f.RankTop != nil && *f.RankTop < len(f.Options) {
+		return *f.RankTop
+	}
+	return len(f.Options)
+}
+
+func (rankingType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if len(f.Options) < 2 {
+		ps = append(ps, Problem{"options", CodeRequired, "ranking requires at least two options"})
+	}
+	if f.RankTop != nil && (*f.RankTop < 1 || *f.RankTop > len(f.Options)) {
+		ps = append(ps, Problem{"rankTop", CodeOutOfRange, "rankTop must be between 1 and the number of options"})
+	}
+	return ps
+}
+
+func (rankingType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	n, _ := arrayLen(v)
+	if n == 0 {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	ranked, ok := asStrings(v)
+	if !ok || len(ranked) != n {
+		return "Must be a list of options"
+	}
+	if n != rankLength(f) {
+		return fmt.Sprintf("Rank exactly %d options", rankLength(f))
+	}
+	seen := make(map[string]struct{}, n)
+	for _, s := range ranked {
+		if !contains(f.Options, s) {
+			return "Invalid option"
+		}
+		if _, dup := seen[s]; dup {
+			return "Each option can be ranked once"
+		}
+		seen[s] = struct{}{}
+	}
+	return ""
+}
+
+func (rankingType) Normalize(f models.Field, v interface{}) interface{} {
+	ranked, _ := asStrings(v)
+	return ranked
+}
+
+// Borda count: with n options, first place scores n-1, second n-2, and so
+// on; options left out of a top-K answer score 0.
+func (rankingType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	n := len(f.Options)
+	rankSum := map[string]int{}
+	rankedN := map[string]int{}
+	first := map[string]int{}
+	borda := map[string]int{}
+	for _, a := range answers {
+		ranked, _ := asStrings(a.Value)
+		for i, s := range ranked {
+			if !contains(f.Options, s) {
+				continue
+			}
+			rankSum[s] += i + 1
+			rankedN[s]++
+			borda[s] += n - 1 - i
+			if i == 0 {
+				first[s]++
+			}
+		}
+	}
+
+	for _, o := range f.Options {
+		st := RankStat{Option: o, FirstChoice: first[o], Borda: borda[o]}
+		if rankedN[o] > 0 {
+			avg := float64(rankSum[o]) / float64(rankedN[o])
+			st.AverageRank = &avg
+		}
+		an.Ranking = append(an.Ranking, st)
+		an.Bars = append(an.Bars, Bar{Label: o, Value: first[o]})
+	}
+	sort.SliceStable(an.Ranking, func(i, j int) bool { return an.Ranking[i].Borda > an.Ranking[j].Borda })
+	an.Summary = "Ranking"
+}
+
+func (rankingType) PDFDetail(an FieldAnalytics) string {
+	if len(an.Ranking) == 0 || an.Ranking[0].Borda == 0 {
+		return ""
+	}
+	return fmt.Sprintf("top by Borda: %s (%d)", an.Ranking[0].Option, an.Ranking[0].Borda)
+}
+
+// RenderPDFSection lists average rank, first-choice count and Borda score
+// per option, best Borda score first.
+func (rankingType) RenderPDFSection(pdf *gofpdf.Fpdf, an FieldAnalytics) {
+	if len(an.Ranking) == 0 {
+		PDFBarTable(pdf, an)
+		return
+	}
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(80, 6, "Option", "1", 0, "", true, 0, "")
+	pdf.CellFormat(30, 6, "Avg rank", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(30, 6, "First choice", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(30, 6, "Borda", "1", 0, "R", true, 0, "")
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, r := range an.Ranking {
+		avg := "-"
+		if r.AverageRank != nil {
+			avg = fmt.Sprintf("%.2f", *r.AverageRank)
+		}
+		pdf.CellFormat(80, 6, r.Option, "1", 0, "", false, 0, "")
+		pdf.CellFormat(30, 6, avg, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%d", r.FirstChoice), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%d", r.Borda), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+}
+
+func (rankingType) FormatForExport(f models.Field, v interface{}) string {
+	ranked, _ := asStrings(v)
+	return strings.Join(ranked, " > ")
+}
+
+func (rankingType) Schema(f models.Field) map[string]interface{} {
+	k := rankLength(f)
+	s := map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string", "enum": f.Options},
+		"uniqueItems": true,
+		"maxItems":    k,
+	}
+	if f.Required {
+		s["minItems"] = k
+	} else {
+		// Either unanswered or a complete ranking.
+		s["anyOf"] = []interface{}{
+			map[string]interface{}{"maxItems": 0},
+			map[string]interface{}{"minItems": k},
+		}
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"strconv"
+
+	"backend/models"
+)
+
+type ratingType struct{}
+
+func init() { Register(ratingType{}) }
+
+func (ratingType) Name() string { return "rating" }
+
+func ratingScale(f models.Field) int {
+	if f.Scale != nil {
+		return *f.Scale
+	}
+	return 5
+}
+
+func (ratingType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if scale := ratingScale(f); scale < 1 || scale > 10 {
+		ps = append(ps, Problem{"scale", CodeOutOfRange, "rating scale must be 1..10"})
+	}
+	if f.Min != nil && *f.Min < 0 {
+		ps = append(ps, Problem{"min", CodeOutOfRange, "rating min must be >= 0"})
+	}
+	return ps
+}
+
+func (ratingType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	num, ok := v.(float64) // JSON numbers -> float64
+	if f.Required && !ok {
+		return "Required"
+	}
+	if !present {
+		return ""
+	}
+	if !ok {
+		return "Invalid rating"
+	}
+	min := 0
+	if f.Min != nil {
+		min = *f.Min
+	}
+	if int(num) < min || int(num) > ratingScale(f) {
+		return "Out of range"
+	}
+	return ""
+}
+
+func (ratingType) Normalize(f models.Field, v interface{}) interface{} { return v }
+
+// Bucket by 1..scale and compute the average.
+func (ratingType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	scale := 5
+	if f.Scale != nil && *f.Scale > 0 && *f.Scale <= 10 {
+		scale = *f.Scale
+	}
+	buckets := make([]int, scale)
+	sum := 0.0
+	n := 0
+
+	for _, a := range answers {
+		v, ok := asFloat(a.Value)
+		if !ok {
+			continue
+		}
+		r := int(v + 0.5)
+		if r < 1 {
+			r = 1
+		}
+		if r > scale {
+			r = scale
+		}
+		buckets[r-1]++
+		sum += float64(r)
+		n++
+	}
+
+	for i, c := range buckets {
+		an.Bars = append(an.Bars, Bar{Label: strconv.Itoa(i + 1), Value: c})
+	}
+	if n > 0 {
+		avg := sum / float64(n)
+		an.Average = &avg
+	}
+	an.Scale = &scale
+	an.Summary = "Rating"
+}
+
+func (ratingType) PDFDetail(an FieldAnalytics) string {
+	if an.Average == nil || an.Scale == nil {
+		return ""
+	}
+	return fmt.Sprintf("avg %.2f / %d", *an.Average, *an.Scale)
+}
+
+func (ratingType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (ratingType) Schema(f models.Field) map[string]interface{} {
+	min := 0
+	if f.Min != nil {
+		min = *f.Min
+	}
+	return map[string]interface{}{"type": "number", "minimum": min, "maximum": ratingScale(f)}
+}
@@ -0,0 +1,262 @@
+package fieldtypes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+
+	"backend/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type signatureType struct{}
+
+func init() { Register(signatureType{}) }
+
+func (signatureType) Name() string { return "signature" }
+
+// Limits on a drawn signature. A stroke answer is
+// {"width": w, "height": h, "strokes": [[[x, y], ...], ...]} in canvas
+// pixels; an image answer is a "data:image/png;base64," URL.
+const (
+	maxSignatureCanvas = 2000
+	maxSignatureStroke = 100
+	maxSignaturePoints = 10000
+	maxSignaturePNG    = 512 << 10
+	pngDataURLPrefix   = "data:image/png;base64,"
+)
+
+// Signature is a decoded signature answer: either Strokes on a Width x
+// Height canvas, or PNG bytes.
+type Signature struct {
+	Width, Height float64
+	Strokes       [][][2]float64
+	PNG           []byte
+}
+
+// ParseSignature decodes a stored or submitted signature answer.
+func ParseSignature(v interface{}) (Signature, error) {
+	if s, ok := v.(string); ok {
+		return parseSignaturePNG(s)
+	}
+	obj, ok := asObject(v)
+	if !ok {
+		return Signature{}, errors.New("Must be strokes or a PNG data URL")
+	}
+	var sig Signature
+	sig.Width, _ = asFloat(obj["width"])
+	sig.Height, _ = asFloat(obj["height"])
+	if sig.Width < 1 || sig.Height < 1 || sig.Width > maxSignatureCanvas || sig.Height > maxSignatureCanvas {
+		return Signature{}, fmt.Errorf("Canvas must be 1..%d pixels each way", maxSignatureCanvas)
+	}
+	strokes, ok := asArray(obj["strokes"])
+	if !ok || len(strokes) == 0 {
+		return Signature{}, errors.New("Signature has no strokes")
+	}
+	if len(strokes) > maxSignatureStroke {
+		return Signature{}, errors.New("Too many strokes")
+	}
+	total := 0
+	for _, st := range strokes {
+		pts, ok := asArray(st)
+		if !ok || len(pts) == 0 {
+			return Signature{}, errors.New("Each stroke must be a list of points")
+		}
+		total += len(pts)
+		if total > maxSignaturePoints {
+			return Signature{}, errors.New("Too many points")
+		}
+		stroke := make([][2]float64, len(pts))
+		for i, p := range pts {
+			xy, ok := asArray(p)
+			if !ok || len(xy) != 2 {
+				return Signature{}, errors.New("Points must be [x, y]")
+			}
+			x, okx := asFloat(xy[0])
+			y, oky := asFloat(xy[1])
+			if !okx || !oky || math.IsNaN(x) || math.IsNaN(y) ||
+				x < 0 || y < 0 || x > sig.Width || y > sig.Height {
+				return Signature{}, errors.New("Point outside the canvas")
+			}
+			stroke[i] = [2]float64{x, y}
+		}
+		sig.Strokes = append(sig.Strokes, stroke)
+	}
+	return sig, nil
+}
+
+func parseSignaturePNG(s string) (Signature, error) {
+	if !strings.HasPrefix(s, pngDataURLPrefix) {
+		return Signature{}, errors.New("Must be strokes or a PNG data URL")
+	}
+	enc := s[len(pngDataURLPrefix):]
+	if base64.StdEncoding.DecodedLen(len(enc)) > maxSignaturePNG {
+		return Signature{}, errors.New("Image is too large")
+	}
+	data, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return Signature{}, errors.New("Invalid base64 image")
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Signature{}, errors.New("Invalid PNG image")
+	}
+	if cfg.Width > maxSignatureCanvas || cfg.Height > maxSignatureCanvas {
+		return Signature{}, fmt.Errorf("Image must be at most %d pixels each way", maxSignatureCanvas)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		return Signature{}, errors.New("Invalid PNG image")
+	}
+	return Signature{Width: float64(cfg.Width), Height: float64(cfg.Height), PNG: data}, nil
+}
+
+// canonicalPNG re-encodes a PNG as 8-bit, non-interlaced RGBA, the form
+// every PDF and image consumer reads; 16-bit and interlaced images are not.
+func canonicalPNG(data []byte) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (signatureType) ValidateDefinition(f models.Field) []Problem { return nil }
+
+func (signatureType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	if v == nil || v == "" {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	if _, err := ParseSignature(v); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// Normalize stores strokes in canonical form, dropping any extra keys, and
+// images as canonical PNGs (see canonicalPNG).
+func (signatureType) Normalize(f models.Field, v interface{}) interface{} {
+	sig, err := ParseSignature(v)
+	if err != nil {
+		return v
+	}
+	if sig.PNG != nil {
+		data, err := canonicalPNG(sig.PNG)
+		if err != nil {
+			return v
+		}
+		return pngDataURLPrefix + base64.StdEncoding.EncodeToString(data)
+	}
+	return map[string]interface{}{"width": sig.Width, "height": sig.Height, "strokes": sig.Strokes}
+}
+
+func (signatureType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	drawn, images := 0, 0
+	for _, a := range answers {
+		if _, ok := a.Value.(string); ok {
+			images++
+		} else if a.Value != nil {
+			drawn++
+		}
+	}
+	an.Bars = []Bar{{Label: "Drawn", Value: drawn}, {Label: "Image", Value: images}}
+	an.Summary = "Signature"
+}
+
+func (signatureType) FormatForExport(f models.Field, v interface{}) string {
+	if _, ok := v.(string); ok {
+		return "signed (image)"
+	}
+	return "signed"
+}
+
+// signatureBox is the largest area a signature is drawn into, in mm.
+const signatureBoxW, signatureBoxH = 80.0, 30.0
+
+// RenderPDFAnswer draws the signature in a framed box, keeping its aspect
+// ratio.
+func (signatureType) RenderPDFAnswer(pdf *gofpdf.Fpdf, f models.Field, v interface{}) error {
+	sig, err := ParseSignature(v)
+	if err != nil {
+		return err
+	}
+	scale := signatureBoxW / sig.Width
+	if s := signatureBoxH / sig.Height; s < scale {
+		scale = s
+	}
+	w, h := sig.Width*scale, sig.Height*scale
+	x, y := pdf.GetX(), pdf.GetY()
+
+	if sig.PNG != nil {
+		opts := gofpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("sig-"+f.ID, opts, bytes.NewReader(sig.PNG))
+		if err := pdf.Error(); err != nil {
+			// gofpdf errors are sticky; clear it so the rest of the document
+			// still renders around the caller's placeholder.
+			pdf.ClearError()
+			return err
+		}
+		pdf.ImageOptions("sig-"+f.ID, x, y, w, h, false, opts, 0, "")
+	} else {
+		pdf.SetDrawColor(20, 20, 60)
+		pdf.SetFillColor(20, 20, 60)
+		pdf.SetLineWidth(0.4)
+		pdf.SetLineCapStyle("round")
+		for _, st := range sig.Strokes {
+			if len(st) == 1 {
+				pdf.Circle(x+st[0][0]*scale, y+st[0][1]*scale, 0.2, "F")
+				continue
+			}
+			for i := 1; i < len(st); i++ {
+				pdf.Line(x+st[i-1][0]*scale, y+st[i-1][1]*scale, x+st[i][0]*scale, y+st[i][1]*scale)
+			}
+		}
+		pdf.SetLineWidth(0.2)
+		pdf.SetDrawColor(0, 0, 0)
+	}
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Rect(x, y, w, h, "D")
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetXY(x, y+h+2)
+	return nil
+}
+
+func (signatureType) Schema(f models.Field) map[string]interface{} {
+	point := map[string]interface{}{
+		"type": "array", "items": map[string]interface{}{"type": "number", "minimum": 0},
+		"minItems": 2, "maxItems": 2,
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "pattern": "^data:image/png;base64,"},
+			map[string]interface{}{
+				"type":     "object",
+				"required": []string{"width", "height", "strokes"},
+				"properties": map[string]interface{}{
+					"width":  map[string]interface{}{"type": "number", "minimum": 1, "maximum": maxSignatureCanvas},
+					"height": map[string]interface{}{"type": "number", "minimum": 1, "maximum": maxSignatureCanvas},
+					"strokes": map[string]interface{}{
+						"type": "array", "minItems": 1, "maxItems": maxSignatureStroke,
+						"items": map[string]interface{}{"type": "array", "minItems": 1, "items": point},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,349 @@
+package fieldtypes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // field timezones must resolve even without system zoneinfo
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// temporalType implements the date, time and datetime field types. Answers
+// are submitted as strings and stored as BSON dates in UTC:
+//
+//	date      "2006-01-02"          -> midnight UTC of that day
+//	time      "15:04" or "15:04:05" -> that time on 1970-01-01 UTC
+//	datetime  RFC 3339, or "2006-01-02T15:04[:05]" in the field's timezone
+type temporalType struct{ kind string }
+
+func init() {
+	Register(temporalType{"date"})
+	Register(temporalType{"time"})
+	Register(temporalType{"datetime"})
+}
+
+func (t temporalType) Name() string { return t.kind }
+
+func fieldLocation(f models.Field) (*time.Location, error) {
+	if f.Timezone == nil || *f.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*f.Timezone)
+}
+
+// parse reads a submitted (or bound) value. The result is in UTC.
+func (t temporalType) parse(f models.Field, s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	switch t.kind {
+	case "date":
+		d, err := time.Parse("2006-01-02", s)
+		return d, err == nil
+	case "time":
+		for _, layout := range []string{"15:04", "15:04:05"} {
+			if d, err := time.Parse(layout, s); err == nil {
+				return time.Date(1970, 1, 1, d.Hour(), d.Minute(), d.Second(), 0, time.UTC), true
+			}
+		}
+		return time.Time{}, false
+	default:
+		if d, err := time.Parse(time.RFC3339, s); err == nil {
+			return d.UTC(), true
+		}
+		loc, err := fieldLocation(f)
+		if err != nil {
+			return time.Time{}, false
+		}
+		for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05"} {
+			if d, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return d.UTC(), true
+			}
+		}
+		return time.Time{}, false
+	}
+}
+
+// asTime reads a stored answer: a BSON date once read back from Mongo, a
+// time.Time straight after normalization, or a string from older data.
+func (t temporalType) asTime(f models.Field, v interface{}) (time.Time, bool) {
+	switch d := v.(type) {
+	case time.Time:
+		return d.UTC(), true
+	case primitive.DateTime:
+		return d.Time().UTC(), true
+	case string:
+		return t.parse(f, d)
+	}
+	return time.Time{}, false
+}
+
+func (t temporalType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if _, err := fieldLocation(f); err != nil {
+		ps = append(ps, Problem{"timezone", CodeInvalid, fmt.Sprintf("unknown timezone %q", *f.Timezone)})
+	}
+	var lo, hi time.Time
+	var okLo, okHi bool
+	if f.Earliest != nil {
+		if lo, okLo = t.parse(f, *f.Earliest); !okLo {
+			ps = append(ps, Problem{"earliest", CodeInvalid, "earliest is not a valid " + t.kind})
+		}
+	}
+	if f.Latest != nil {
+		if hi, okHi = t.parse(f, *f.Latest); !okHi {
+			ps = append(ps, Problem{"latest", CodeInvalid, "latest is not a valid " + t.kind})
+		}
+	}
+	if okLo && okHi && lo.After(hi) {
+		ps = append(ps, Problem{"latest", CodeMinOverMax, "earliest cannot be after latest"})
+	}
+	if f.DisallowPast && t.kind == "time" {
+		ps = append(ps, Problem{"disallowPast", CodeInvalid, "disallowPast does not apply to time fields"})
+	}
+	return ps
+}
+
+// inPast reports whether d is before today (date) or now (datetime).
+func (t temporalType) inPast(f models.Field, d time.Time, now time.Time) bool {
+	if t.kind == "date" {
+		loc, err := fieldLocation(f)
+		if err != nil {
+			loc = time.UTC
+		}
+		y, m, day := now.In(loc).Date()
+		return d.Before(time.Date(y, m, day, 0, 0, 0, 0, time.UTC))
+	}
+	return d.Before(now)
+}
+
+func (t temporalType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	if !present || isBlank(v) {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	s, _ := v.(string)
+	d, ok := t.parse(f, s)
+	if !ok {
+		return "Invalid " + t.kind
+	}
+	if f.Earliest != nil {
+		if lo, ok := t.parse(f, *f.Earliest); ok && d.Before(lo) {
+			return "Too early"
+		}
+	}
+	if f.Latest != nil {
+		if hi, ok := t.parse(f, *f.Latest); ok && d.After(hi) {
+			return "Too late"
+		}
+	}
+	if f.DisallowPast && t.inPast(f, d, time.Now()) {
+		return "Cannot be in the past"
+	}
+	return ""
+}
+
+func (t temporalType) Normalize(f models.Field, v interface{}) interface{} {
+	s, _ := v.(string)
+	if d, ok := t.parse(f, s); ok {
+		return d
+	}
+	return nil
+}
+
+func (t temporalType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	loc, err := fieldLocation(f)
+	if err != nil || t.kind != "datetime" {
+		loc = time.UTC // dates and times are stored as UTC wall clock values
+	}
+	var ds []time.Time
+	for _, a := range answers {
+		if d, ok := t.asTime(f, a.Value); ok {
+			ds = append(ds, d.In(loc))
+		}
+	}
+
+	if t.kind == "time" {
+		an.Bucket = "hour"
+		counts := make([]int, 24)
+		for _, d := range ds {
+			counts[d.Hour()]++
+		}
+		for h, c := range counts {
+			an.Bars = append(an.Bars, Bar{Label: fmt.Sprintf("%02d:00", h), Value: c})
+		}
+		an.Summary = "Time"
+		return
+	}
+
+	an.Bucket, an.Bars = timeHistogram(ds)
+	weekdays := make([]int, 7)
+	for _, d := range ds {
+		weekdays[(int(d.Weekday())+6)%7]++ // Monday first
+	}
+	for i, c := range weekdays {
+		an.Weekdays = append(an.Weekdays, Bar{Label: time.Weekday((i + 1) % 7).String()[:3], Value: c})
+	}
+	if t.kind == "date" {
+		an.Summary = "Date"
+	} else {
+		an.Summary = "Date & time"
+	}
+}
+
+// timeBuckets splits the span [lo, hi] into days, weeks (starting Monday),
+// months or years, picking the finest granularity that keeps a chart to a
+// sensible number of bars.
+type timeBuckets struct {
+	kind string // day | week | month | year
+}
+
+// maxTimeBars bounds a time chart. Spans that would need more year bars
+// (answers centuries apart) only get bars for the years that have answers.
+const maxTimeBars = 200
+
+func newTimeBuckets(lo, hi time.Time) timeBuckets {
+	span := hi.Sub(lo)
+	switch {
+	case span <= 31*24*time.Hour:
+		return timeBuckets{"day"}
+	case span <= 26*7*24*time.Hour:
+		return timeBuckets{"week"}
+	case hi.Year()-lo.Year() < 10:
+		return timeBuckets{"month"}
+	}
+	return timeBuckets{"year"}
+}
+
+// start returns the beginning of the bucket containing d.
+func (b timeBuckets) start(d time.Time) time.Time {
+	y, m, day := d.Date()
+	switch b.kind {
+	case "day":
+		return time.Date(y, m, day, 0, 0, 0, 0, d.Location())
+	case "week":
+		back := (int(d.Weekday()) + 6) % 7
+		return time.Date(y, m, day-back, 0, 0, 0, 0, d.Location())
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, d.Location())
+	default:
+		return time.Date(y, m, 1, 0, 0, 0, 0, d.Location())
+	}
+}
+
+func (b timeBuckets) next(d time.Time) time.Time {
+	switch b.kind {
+	case "day":
+		return d.AddDate(0, 0, 1)
+	case "week":
+		return d.AddDate(0, 0, 7)
+	case "year":
+		return d.AddDate(1, 0, 0)
+	default:
+		return d.AddDate(0, 1, 0)
+	}
+}
+
+func (b timeBuckets) label(d time.Time) string {
+	switch b.kind {
+	case "week":
+		return "Week of " + d.Format("2006-01-02")
+	case "month":
+		return d.Format("2006-01")
+	case "year":
+		return d.Format("2006")
+	default:
+		return d.Format("2006-01-02")
+	}
+}
+
+// starts returns the bucket starts to chart for ds: every bucket from the
+// first to the last answer, or only the non-empty ones in order when that
+// would exceed maxTimeBars.
+func (b timeBuckets) starts(ds []time.Time) []time.Time {
+	lo, hi := timeSpan(ds)
+	var out []time.Time
+	for s := b.start(lo); !s.After(hi); s = b.next(s) {
+		if len(out) == maxTimeBars {
+			out = nil
+			seen := map[int64]bool{}
+			for _, d := range ds {
+				s := b.start(d)
+				if !seen[s.Unix()] {
+					seen[s.Unix()] = true
+					out = append(out, s)
+				}
+			}
+			sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+			return out
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// timeSpan returns the earliest and latest of ds, which must not be empty.
+func timeSpan(ds []time.Time) (lo, hi time.Time) {
+	lo, hi = ds[0], ds[0]
+	for _, d := range ds {
+		if d.Before(lo) {
+			lo = d
+		}
+		if d.After(hi) {
+			hi = d
+		}
+	}
+	return lo, hi
+}
+
+// timeHistogram counts ds per day, week, month or year (see timeBuckets).
+func timeHistogram(ds []time.Time) (string, []Bar) {
+	if len(ds) == 0 {
+		return "day", []Bar{}
+	}
+	tb := newTimeBuckets(timeSpan(ds))
+
+	counts := map[int64]int{}
+	for _, d := range ds {
+		counts[tb.start(d).Unix()]++
+	}
+	var bars []Bar
+	for _, b := range tb.starts(ds) {
+		bars = append(bars, Bar{Label: tb.label(b), Value: counts[b.Unix()]})
+	}
+	return tb.kind, bars
+}
+
+func (t temporalType) FormatForExport(f models.Field, v interface{}) string {
+	d, ok := t.asTime(f, v)
+	if !ok {
+		return FormatValue(v)
+	}
+	switch t.kind {
+	case "date":
+		return d.Format("2006-01-02")
+	case "time":
+		return d.Format("15:04:05")
+	}
+	if loc, err := fieldLocation(f); err == nil {
+		d = d.In(loc)
+	}
+	return d.Format(time.RFC3339)
+}
+
+func (t temporalType) Schema(f models.Field) map[string]interface{} {
+	switch t.kind {
+	case "date":
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case "time":
+		return map[string]interface{}{"type": "string", "pattern": `^\d{2}:\d{2}(:\d{2})?$`}
+	}
+	return map[string]interface{}{
+		"type":    "string",
+		"pattern": `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}`,
+	}
+}
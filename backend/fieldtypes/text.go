@@ -0,0 +1,93 @@
+package fieldtypes
+
+import (
+	"strings"
+
+	"backend/models"
+)
+
+type textType struct{}
+
+func init() { Register(textType{}) }
+
+func (textType) Name() string { return "text" }
+
+func (textType) ValidateDefinition(f models.Field) []Problem {
+	var ps []Problem
+	if f.MinLength != nil && *f.MinLength < 0 {
+		ps = append(ps, Problem{"minLength", CodeOutOfRange, "minLength must be >= 0"})
+	}
+	if f.MaxLength != nil && *f.MaxLength < 0 {
+		ps = append(ps, Problem{"maxLength", CodeOutOfRange, "maxLength must be >= 0"})
+	}
+	if f.MinLength != nil && f.MaxLength != nil && *f.MinLength > *f.MaxLength {
+		ps = append(ps, Problem{"maxLength", CodeMinOverMax, "minLength cannot exceed maxLength"})
+	}
+	return ps
+}
+
+func (textType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if f.Required && strings.TrimSpace(s) == "" {
+		return "Required"
+	}
+	if !present {
+		return ""
+	}
+	n := len([]rune(s))
+	if f.MaxLength != nil && n > *f.MaxLength {
+		return "Max length exceeded"
+	}
+	if f.MinLength != nil && n < *f.MinLength {
+		return "Min length not met"
+	}
+	return ""
+}
+
+func (textType) Normalize(f models.Field, v interface{}) interface{} { return v }
+
+// Text answers are summarized as a length distribution.
+func (textType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	bins := []struct {
+		label     string
+		lo, hiInt int
+	}{
+		{"0–20", 0, 20},
+		{"21–50", 21, 50},
+		{"51–100", 51, 100},
+		{"101–200", 101, 200},
+		{"200+", 201, 1 << 30},
+	}
+	counts := make([]int, len(bins))
+	for _, a := range answers {
+		s, _ := a.Value.(string)
+		l := len([]rune(s))
+		for i, b := range bins {
+			if l >= b.lo && l <= b.hiInt {
+				counts[i]++
+				break
+			}
+		}
+	}
+	for i, b := range bins {
+		an.Bars = append(an.Bars, Bar{Label: b.label, Value: counts[i]})
+	}
+	an.Summary = "Text"
+}
+
+func (textType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (textType) Schema(f models.Field) map[string]interface{} {
+	s := map[string]interface{}{"type": "string"}
+	if f.MinLength != nil {
+		s["minLength"] = *f.MinLength
+	}
+	if f.MaxLength != nil {
+		s["maxLength"] = *f.MaxLength
+	}
+	if f.Required {
+		// Whitespace-only answers don't satisfy a required text field
+		s["pattern"] = `\S`
+	}
+	return s
+}
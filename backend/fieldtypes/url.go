@@ -0,0 +1,70 @@
+package fieldtypes
+
+import (
+	"net/url"
+	"strings"
+
+	"backend/models"
+)
+
+type urlType struct{}
+
+func init() { Register(urlType{}) }
+
+func (urlType) Name() string { return "url" }
+
+func (urlType) ValidateDefinition(f models.Field) []Problem { return nil }
+
+// normalizeURL accepts absolute http(s) URLs and lowercases scheme and host.
+func normalizeURL(s string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || !u.IsAbs() || u.Host == "" || u.Opaque != "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), true
+}
+
+func (urlType) ValidateAnswer(f models.Field, v interface{}, present bool) string {
+	s, _ := v.(string)
+	if strings.TrimSpace(s) == "" {
+		if f.Required {
+			return "Required"
+		}
+		return ""
+	}
+	if _, ok := normalizeURL(s); !ok {
+		return "Invalid URL"
+	}
+	return ""
+}
+
+func (urlType) Normalize(f models.Field, v interface{}) interface{} {
+	s, _ := v.(string)
+	if n, ok := normalizeURL(s); ok {
+		return n
+	}
+	return strings.TrimSpace(s)
+}
+
+// URL answers are summarized by host.
+func (urlType) Aggregate(f models.Field, answers []Answer, an *FieldAnalytics) {
+	an.Bars = aggregateBreakdown(answers, func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	})
+	an.Summary = "URL hosts"
+}
+
+func (urlType) FormatForExport(f models.Field, v interface{}) string { return FormatValue(v) }
+
+func (urlType) Schema(f models.Field) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "uri", "pattern": "^[Hh][Tt][Tt][Pp][Ss]?://"}
+}
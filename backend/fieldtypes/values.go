@@ -0,0 +1,129 @@
+package fieldtypes
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Answers arrive as JSON-decoded values on submit and as BSON-decoded values
+// when read back from Mongo; these helpers accept both.
+
+// asStrings returns the string items of an array answer; ok is false if v is
+// not an array. Non-string items are skipped.
+func asStrings(v interface{}) ([]string, bool) {
+	switch arr := v.(type) {
+	case []string:
+		return arr, true
+	case []interface{}:
+		return stringItems(arr), true
+	case primitive.A:
+		return stringItems(arr), true
+	}
+	return nil, false
+}
+
+func stringItems(arr []interface{}) []string {
+	out := make([]string, 0, len(arr))
+	for _, it := range arr {
+		if s, ok := it.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// asArray returns the items of an array answer of any element type.
+func asArray(v interface{}) ([]interface{}, bool) {
+	switch arr := v.(type) {
+	case []interface{}:
+		return arr, true
+	case primitive.A:
+		return arr, true
+	}
+	return nil, false
+}
+
+// arrayLen returns the length of an array answer, counting every item.
+func arrayLen(v interface{}) (int, bool) {
+	switch arr := v.(type) {
+	case []string:
+		return len(arr), true
+	case []interface{}:
+		return len(arr), true
+	case primitive.A:
+		return len(arr), true
+	}
+	return 0, false
+}
+
+// asFloat returns a numeric answer as float64.
+func asFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// asObject returns an object answer as a map; ok is false if v is not an
+// object. Mongo decodes embedded documents as primitive.D.
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		return o, true
+	case primitive.M:
+		return o, true
+	case primitive.D:
+		return o.Map(), true
+	}
+	return nil, false
+}
+
+// IsEmpty reports whether an answer counts as not given: missing, blank
+// text, an empty list or an empty object.
+func IsEmpty(v interface{}) bool {
+	if v == nil || isBlank(v) {
+		return true
+	}
+	if n, ok := arrayLen(v); ok {
+		return n == 0
+	}
+	if obj, ok := asObject(v); ok {
+		return len(obj) == 0
+	}
+	return false
+}
+
+// FormatValue renders any answer value as text.
+func FormatValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return fmt.Sprintf("%g", t)
+	case int:
+		return fmt.Sprintf("%d", t)
+	case int32:
+		return fmt.Sprintf("%d", t)
+	case int64:
+		return fmt.Sprintf("%d", t)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+func contains(options []string, s string) bool {
+	for _, o := range options {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}
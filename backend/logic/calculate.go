@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"backend/fieldtypes"
+	"backend/models"
+)
+
+// Calculate evaluates every calculated field over answers and returns the
+// results by field id. Calculated fields may read each other; they are
+// evaluated in dependency order. A field whose expression fails to parse or
+// evaluate, or yields null, is left out.
+func Calculate(fields []models.Field, answers map[string]interface{}) map[string]interface{} {
+	exprs := map[string]*Expr{}
+	var order []string
+	for _, f := range fields {
+		src, computed := fieldtypes.ExpressionOf(f)
+		if !computed {
+			continue
+		}
+		e, err := ParseExpr(src)
+		if err != nil {
+			continue
+		}
+		exprs[f.ID] = e
+		order = append(order, f.ID)
+	}
+
+	env := make(map[string]interface{}, len(answers)+len(exprs))
+	for k, v := range answers {
+		if _, calc := exprs[k]; !calc {
+			env[k] = v
+		}
+	}
+	out := make(map[string]interface{}, len(exprs))
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := map[string]int{}
+	var visit func(id string)
+	visit = func(id string) {
+		if state[id] != 0 {
+			return // done, or a cycle (rejected on save): leave unset
+		}
+		state[id] = visiting
+		e := exprs[id]
+		for _, ref := range e.Refs() {
+			if _, calc := exprs[ref]; calc {
+				visit(ref)
+			}
+		}
+		if v, err := e.Eval(env); err == nil && v != nil {
+			env[id], out[id] = v, v
+		}
+		state[id] = done
+	}
+	for _, id := range order {
+		visit(id)
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+// Package logic evaluates the rules a form definition can carry on top of
+// its fields: visibility conditions, page branching (Walk), calculated-field
+// expressions and quiz grading. Everything here works on plain answer maps
+// so the same rules run on submit and when reading stored responses.
+package logic
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/fieldtypes"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OpEquals      = "equals"
+	OpContains    = "contains"
+	OpGreaterThan = "greaterThan"
+)
+
+// Refs lists the field ids a condition reads, in order of appearance.
+func Refs(c *models.Condition) []string {
+	if c == nil {
+		return nil
+	}
+	var out []string
+	if c.Field != "" {
+		out = append(out, c.Field)
+	}
+	for i := range c.All {
+		out = append(out, Refs(&c.All[i])...)
+	}
+	for i := range c.Any {
+		out = append(out, Refs(&c.Any[i])...)
+	}
+	return out
+}
+
+// RenameRefs returns a deep copy of c with field references renamed through
+// ids. References not in ids are kept as they are.
+func RenameRefs(c models.Condition, ids map[string]string) models.Condition {
+	if to, ok := ids[c.Field]; ok {
+		c.Field = to
+	}
+	if c.All != nil {
+		all := make([]models.Condition, len(c.All))
+		for i, sub := range c.All {
+			all[i] = RenameRefs(sub, ids)
+		}
+		c.All = all
+	}
+	if c.Any != nil {
+		anyOf := make([]models.Condition, len(c.Any))
+		for i, sub := range c.Any {
+			anyOf[i] = RenameRefs(sub, ids)
+		}
+		c.Any = anyOf
+	}
+	return c
+}
+
+// CheckCondition validates the shape of a condition and that every field it
+// references is in known. Paths are relative to the condition ("all[1].op").
+func CheckCondition(c models.Condition, known map[string]bool) []fieldtypes.Problem {
+	var ps []fieldtypes.Problem
+	leaf := c.Field != "" || c.Op != "" || c.Value != nil
+	switch {
+	case leaf && (c.All != nil || c.Any != nil), c.All != nil && c.Any != nil:
+		ps = append(ps, fieldtypes.Problem{Path: "", Code: fieldtypes.CodeInvalid,
+			Message: "a condition is either a comparison, an all group or an any group"})
+	case c.All != nil || c.Any != nil:
+		group, name := c.All, "all"
+		if c.Any != nil {
+			group, name = c.Any, "any"
+		}
+		if len(group) == 0 {
+			ps = append(ps, fieldtypes.Problem{Path: name, Code: fieldtypes.CodeRequired, Message: "condition group is empty"})
+		}
+		for i, sub := range group {
+			for _, p := range CheckCondition(sub, known) {
+				p.Path = joinPath(fmt.Sprintf("%s[%d]", name, i), p.Path)
+				ps = append(ps, p)
+			}
+		}
+	default:
+		if c.Field == "" {
+			ps = append(ps, fieldtypes.Problem{Path: "field", Code: fieldtypes.CodeRequired, Message: "condition must name a field"})
+		} else if !known[c.Field] {
+			ps = append(ps, fieldtypes.Problem{Path: "field", Code: fieldtypes.CodeUnknownRef, Message: fmt.Sprintf("condition references unknown field %q", c.Field)})
+		}
+		switch c.Op {
+		case OpEquals, OpContains:
+		case OpGreaterThan:
+			if _, ok := number(c.Value); !ok {
+				if _, ok := c.Value.(string); !ok {
+					ps = append(ps, fieldtypes.Problem{Path: "value", Code: fieldtypes.CodeInvalid, Message: "greaterThan needs a number or string value"})
+				}
+			}
+		default:
+			ps = append(ps, fieldtypes.Problem{Path: "op", Code: fieldtypes.CodeInvalid, Message: fmt.Sprintf("unknown condition op %q", c.Op)})
+		}
+		if c.Value == nil {
+			ps = append(ps, fieldtypes.Problem{Path: "value", Code: fieldtypes.CodeRequired, Message: "condition must have a value"})
+		}
+	}
+	return ps
+}
+
+func joinPath(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + "." + b
+}
+
+// Evaluate reports whether c holds for answers. An unanswered field fails
+// every comparison.
+func Evaluate(c models.Condition, answers map[string]interface{}) bool {
+	switch {
+	case c.All != nil:
+		for _, sub := range c.All {
+			if !Evaluate(sub, answers) {
+				return false
+			}
+		}
+		return true
+	case c.Any != nil:
+		for _, sub := range c.Any {
+			if Evaluate(sub, answers) {
+				return true
+			}
+		}
+		return false
+	}
+	v, ok := answers[c.Field]
+	if !ok || fieldtypes.IsEmpty(v) {
+		return false
+	}
+	switch c.Op {
+	case OpEquals:
+		if items, ok := list(v); ok {
+			want, isList := list(c.Value)
+			return isList && sameSet(items, want)
+		}
+		return equal(v, c.Value)
+	case OpContains:
+		if items, ok := list(v); ok {
+			for _, it := range items {
+				if equal(it, c.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		s, ok1 := v.(string)
+		sub, ok2 := c.Value.(string)
+		return ok1 && ok2 && strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+	case OpGreaterThan:
+		if a, ok := number(v); ok {
+			b, ok := number(c.Value)
+			return ok && a > b
+		}
+		// Strings compare lexically, which orders ISO dates and times.
+		a, ok1 := v.(string)
+		b, ok2 := c.Value.(string)
+		return ok1 && ok2 && a > b
+	}
+	return false
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func list(v interface{}) ([]interface{}, bool) {
+	switch arr := v.(type) {
+	case []interface{}:
+		return arr, true
+	case primitive.A:
+		return arr, true
+	case []string:
+		out := make([]interface{}, len(arr))
+		for i, s := range arr {
+			out[i] = s
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return a == b
+}
+
+func sameSet(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if equal(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
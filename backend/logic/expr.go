@@ -0,0 +1,785 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The expression language used by calculated fields. It has no statements,
+// loops or assignment, and can only read answers, so every expression ends.
+//
+//	literals     12  3.5  'text'  "text"  true  false  null
+//	answers      q1  age  {3kx9a0bq}   (braces for ids that are not names)
+//	operators    ?:  ||  &&  == !=  < <= > >=  + -  * / %  !x  -x  ( )
+//	functions    abs floor ceil round(x[, digits]) min max sum len contains number
+//
+// Unanswered fields are null. Arithmetic treats null as 0, so totals over
+// optional questions work; comparisons other than == and != with null are
+// false. + concatenates strings. Any other type mismatch, or division by
+// zero, is an evaluation error.
+
+const (
+	maxExprLength = 2000
+	maxExprDepth  = 64
+)
+
+// Expr is a parsed expression.
+type Expr struct {
+	root node
+	refs []string
+}
+
+// ParseExpr parses src, or reports the first syntax error with its offset.
+func ParseExpr(src string) (*Expr, error) {
+	if len(src) > maxExprLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxExprLength)
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("at %d: unexpected %q", t.pos, t.text)
+	}
+	e := &Expr{root: root}
+	seen := map[string]bool{}
+	for i, t := range toks {
+		if t.kind == tokIdent && !seen[t.text] && !isCall(toks, i) {
+			seen[t.text] = true
+			e.refs = append(e.refs, t.text)
+		}
+	}
+	return e, nil
+}
+
+// Refs lists the field ids the expression reads, in order of appearance.
+func (e *Expr) Refs() []string { return e.refs }
+
+// Eval computes the expression over answers.
+func (e *Expr) Eval(answers map[string]interface{}) (interface{}, error) {
+	v, err := e.root.eval(answers)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, errors.New("result is not a finite number")
+	}
+	return v, nil
+}
+
+// RenameExprRefs rewrites the field references in src through ids, leaving
+// everything else (spacing, literals) as written.
+func RenameExprRefs(src string, ids map[string]string) (string, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return src, err
+	}
+	var b strings.Builder
+	last := 0
+	for i, t := range toks {
+		to, ok := ids[t.text]
+		if t.kind != tokIdent || !ok || isCall(toks, i) {
+			continue
+		}
+		b.WriteString(src[last:t.pos])
+		b.WriteString(refSyntax(to))
+		last = t.end
+	}
+	b.WriteString(src[last:])
+	return b.String(), nil
+}
+
+// refSyntax writes id bare when it lexes as a name, in braces otherwise.
+func refSyntax(id string) string {
+	r, _ := utf8.DecodeRuneInString(id)
+	if id != "" && (unicode.IsLetter(r) || r == '_') && !keywords[id] && strings.IndexFunc(id, notNameRune) < 0 {
+		return id
+	}
+	return "{" + id + "}"
+}
+
+func notNameRune(r rune) bool { return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') }
+
+var keywords = map[string]bool{"true": true, "false": true, "null": true}
+
+// ---------- lexer ----------
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokKeyword
+	tokOp
+)
+
+type token struct {
+	kind     tokKind
+	text     string // identifier name, literal value or operator
+	pos, end int    // byte offsets in the source
+}
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ",", "?", ":"}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r >= '0' && r <= '9' || r == '.':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			if _, err := strconv.ParseFloat(src[i:j], 64); err != nil {
+				return nil, fmt.Errorf("at %d: invalid number %q", i, src[i:j])
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i, j})
+			i = j
+
+		case r == '\'' || r == '"':
+			var b strings.Builder
+			j := i + 1
+			for {
+				if j >= len(src) {
+					return nil, fmt.Errorf("at %d: unterminated string", i)
+				}
+				if src[j] == byte(r) {
+					break
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			toks = append(toks, token{tokString, b.String(), i, j + 1})
+			i = j + 1
+
+		case r == '{':
+			j := strings.IndexByte(src[i:], '}')
+			if j < 0 {
+				return nil, fmt.Errorf("at %d: unterminated {field id}", i)
+			}
+			id := strings.TrimSpace(src[i+1 : i+j])
+			if id == "" {
+				return nil, fmt.Errorf("at %d: empty {field id}", i)
+			}
+			toks = append(toks, token{tokIdent, id, i, i + j + 1})
+			i += j + 1
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if notNameRune(r) {
+					break
+				}
+				j += size
+			}
+			kind := tokIdent
+			if keywords[src[i:j]] {
+				kind = tokKeyword
+			}
+			toks = append(toks, token{kind, src[i:j], i, j})
+			i = j
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, token{tokOp, op, i, i + len(op)})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("at %d: unexpected %q", i, r)
+			}
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src), len(src)}), nil
+}
+
+// isCall reports whether identifier toks[i] names a function, i.e. is
+// followed by "(".
+func isCall(toks []token, i int) bool {
+	return i+1 < len(toks) && toks[i+1].kind == tokOp && toks[i+1].text == "("
+}
+
+// ---------- parser ----------
+
+type parser struct {
+	toks  []token
+	i     int
+	depth int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.i++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return fmt.Errorf("at %d: expected %q, found %q", t.pos, op, t.text)
+	}
+	return nil
+}
+
+func (p *parser) ternary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExprDepth {
+		return nil, errors.New("expression is nested too deeply")
+	}
+	c, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return c, nil
+	}
+	t, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	f, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return condNode{c, t, f}, nil
+}
+
+// levels lists binary operators from loosest to tightest binding.
+var levels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(levels) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(levels[level]...)
+		if !ok {
+			return l, nil
+		}
+		r, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op, l, r}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExprDepth {
+			return nil, errors.New("expression is nested too deeply")
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op, x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, _ := strconv.ParseFloat(t.text, 64)
+		return literal{f}, nil
+	case tokString:
+		return literal{t.text}, nil
+	case tokKeyword:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		}
+		return literal{nil}, nil
+	case tokIdent:
+		if _, ok := p.accept("("); !ok {
+			return refNode{t.text}, nil
+		}
+		fn, ok := functions[t.text]
+		if !ok {
+			return nil, fmt.Errorf("at %d: unknown function %q", t.pos, t.text)
+		}
+		var args []node
+		if _, ok := p.accept(")"); !ok {
+			for {
+				a, err := p.ternary()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, a)
+				if _, ok := p.accept(","); !ok {
+					break
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+			return nil, fmt.Errorf("at %d: wrong number of arguments to %s", t.pos, t.text)
+		}
+		return callNode{t.text, fn.call, args}, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("at %d: unexpected %q", t.pos, t.text)
+}
+
+// ---------- interpreter ----------
+
+type node interface {
+	eval(answers map[string]interface{}) (interface{}, error)
+}
+
+type literal struct{ v interface{} }
+
+type refNode struct{ id string }
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+type condNode struct{ c, t, f node }
+
+type callNode struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+	args []node
+}
+
+func (n literal) eval(map[string]interface{}) (interface{}, error) { return n.v, nil }
+
+func (n refNode) eval(answers map[string]interface{}) (interface{}, error) {
+	return exprValue(answers[n.id])
+}
+
+// exprValue converts an answer to the language's types: float64, string,
+// bool, nil or []interface{}.
+func exprValue(v interface{}) (interface{}, error) {
+	if f, ok := number(v); ok {
+		return f, nil
+	}
+	switch x := v.(type) {
+	case nil, string, bool:
+		return x, nil
+	}
+	if items, ok := list(v); ok {
+		out := make([]interface{}, len(items))
+		for i, it := range items {
+			var err error
+			if out[i], err = exprValue(it); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("answer of type %T cannot be used in expressions", v)
+}
+
+func (n unaryNode) eval(answers map[string]interface{}) (interface{}, error) {
+	x, err := n.x.eval(answers)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(x), nil
+	}
+	f, err := arith(x)
+	return -f, err
+}
+
+func (n binaryNode) eval(answers map[string]interface{}) (interface{}, error) {
+	l, err := n.l.eval(answers)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op { // short-circuit
+	case "&&":
+		if !truthy(l) {
+			return false, nil
+		}
+	case "||":
+		if truthy(l) {
+			return true, nil
+		}
+	}
+	r, err := n.r.eval(answers)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return truthy(r), nil
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, l, r)
+	case "+":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if lok && (rok || r == nil) || rok && l == nil {
+			return ls + rs, nil
+		}
+	}
+
+	a, err := arith(l)
+	if err != nil {
+		return nil, err
+	}
+	b, err := arith(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n condNode) eval(answers map[string]interface{}) (interface{}, error) {
+	c, err := n.c.eval(answers)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(c) {
+		return n.t.eval(answers)
+	}
+	return n.f.eval(answers)
+}
+
+func (n callNode) eval(answers map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		var err error
+		if args[i], err = a.eval(answers); err != nil {
+			return nil, err
+		}
+	}
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.name, err)
+	}
+	return v, nil
+}
+
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	case []interface{}:
+		return len(x) > 0
+	}
+	return true
+}
+
+// arith reads an operand of an arithmetic operator; null counts as 0.
+func arith(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return x, nil
+	}
+	return 0, fmt.Errorf("%s is not a number", describe(v))
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "text"
+	case bool:
+		return "true/false"
+	case []interface{}:
+		return "a list"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func exprEqual(a, b interface{}) bool {
+	al, aok := a.([]interface{})
+	bl, bok := b.([]interface{})
+	if aok || bok {
+		if !aok || !bok || len(al) != len(bl) {
+			return false
+		}
+		for i := range al {
+			if !exprEqual(al[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func compare(op string, l, r interface{}) (interface{}, error) {
+	if l == nil || r == nil {
+		return false, nil
+	}
+	var c int
+	switch a := l.(type) {
+	case float64:
+		b, ok := r.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare a number with %s", describe(r))
+		}
+		c = cmpFloat(a, b)
+	case string:
+		b, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare text with %s", describe(r))
+		}
+		c = strings.Compare(a, b)
+	default:
+		return nil, fmt.Errorf("cannot order %s", describe(l))
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ---------- functions ----------
+
+type function struct {
+	minArgs, maxArgs int // maxArgs -1: variadic
+	call             func(args []interface{}) (interface{}, error)
+}
+
+var functions map[string]function
+
+func init() {
+	math1 := func(f func(float64) float64) function {
+		return function{1, 1, func(args []interface{}) (interface{}, error) {
+			x, err := arith(args[0])
+			return f(x), err
+		}}
+	}
+	functions = map[string]function{
+		"abs":   math1(math.Abs),
+		"floor": math1(math.Floor),
+		"ceil":  math1(math.Ceil),
+		"round": {1, 2, func(args []interface{}) (interface{}, error) {
+			x, err := arith(args[0])
+			if err != nil {
+				return nil, err
+			}
+			digits := 0.0
+			if len(args) == 2 {
+				if digits, err = arith(args[1]); err != nil {
+					return nil, err
+				}
+			}
+			p := math.Pow(10, math.Trunc(digits))
+			return math.Round(x*p) / p, nil
+		}},
+		"min": {1, -1, func(args []interface{}) (interface{}, error) {
+			return fold(args, func(acc, x float64) float64 { return math.Min(acc, x) })
+		}},
+		"max": {1, -1, func(args []interface{}) (interface{}, error) {
+			return fold(args, func(acc, x float64) float64 { return math.Max(acc, x) })
+		}},
+		"sum": {1, -1, func(args []interface{}) (interface{}, error) {
+			xs, err := numbers(args)
+			total := 0.0
+			for _, x := range xs {
+				total += x
+			}
+			return total, err
+		}},
+		"len": {1, 1, func(args []interface{}) (interface{}, error) {
+			switch x := args[0].(type) {
+			case nil:
+				return 0.0, nil
+			case string:
+				return float64(utf8.RuneCountInString(x)), nil
+			case []interface{}:
+				return float64(len(x)), nil
+			}
+			return nil, fmt.Errorf("%s has no length", describe(args[0]))
+		}},
+		"contains": {2, 2, func(args []interface{}) (interface{}, error) {
+			switch x := args[0].(type) {
+			case nil:
+				return false, nil
+			case string:
+				sub, ok := args[1].(string)
+				return ok && strings.Contains(x, sub), nil
+			case []interface{}:
+				for _, it := range x {
+					if exprEqual(it, args[1]) {
+						return true, nil
+					}
+				}
+				return false, nil
+			}
+			return nil, fmt.Errorf("cannot search %s", describe(args[0]))
+		}},
+		"number": {1, 1, func(args []interface{}) (interface{}, error) {
+			switch x := args[0].(type) {
+			case nil, float64:
+				return x, nil
+			case string:
+				f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+				if err != nil {
+					return nil, nil // not a number: treat as unanswered
+				}
+				return f, nil
+			}
+			return nil, fmt.Errorf("cannot convert %s to a number", describe(args[0]))
+		}},
+	}
+}
+
+// numbers flattens list arguments and skips nulls.
+func numbers(args []interface{}) ([]float64, error) {
+	var out []float64
+	for _, a := range args {
+		if items, ok := a.([]interface{}); ok {
+			xs, err := numbers(items)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, xs...)
+			continue
+		}
+		if a == nil {
+			continue
+		}
+		x, err := arith(a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+func fold(args []interface{}, f func(acc, x float64) float64) (interface{}, error) {
+	xs, err := numbers(args)
+	if err != nil || len(xs) == 0 {
+		return nil, err
+	}
+	acc := xs[0]
+	for _, x := range xs[1:] {
+		acc = f(acc, x)
+	}
+	return acc, nil
+}
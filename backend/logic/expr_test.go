@@ -0,0 +1,172 @@
+package logic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExprEval(t *testing.T) {
+	answers := map[string]interface{}{
+		"a":     2.0,
+		"b":     int32(3), // as read back from Mongo
+		"name":  "Ada",
+		"tags":  []interface{}{"x", "y"},
+		"yes":   true,
+		"3kx9a": 10.0,
+	}
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		// precedence and associativity
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"2 * 3 % 4", 2.0},
+		{"-2 * -3", 6.0},
+		{"1 + 2 > 2 && 1 < 2", true},
+		{"false || true && false", false},
+		{"!false == true", true},
+		{"a > 1 ? b : 0", 3.0},
+		{"true ? false ? 1 : 2 : 3", 2.0},
+		{"{3kx9a} / a", 5.0},
+
+		// null arithmetic and comparison
+		{"missing + 1", 1.0},
+		{"missing * a", 0.0},
+		{"-missing", 0.0},
+		{"missing == null", true},
+		{"missing != 0", true},
+		{"missing > 0", false},
+		{"missing < 0", false},
+		{"name + missing", "Ada"},
+		{"name + ' L.'", "Ada L."},
+
+		// functions
+		{"round(2.345, 2)", 2.35},
+		{"min(a, b, 1)", 1.0},
+		{"sum(a, b)", 5.0},
+		{"len(tags)", 2.0},
+		{"contains(tags, 'y')", true},
+		{"number('4.5') + 1", 5.5},
+		{"yes && len(name) == 3", true},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.src)
+		if err != nil {
+			t.Errorf("%s: parse: %v", tt.src, err)
+			continue
+		}
+		got, err := e.Eval(answers)
+		if err != nil {
+			t.Errorf("%s: eval: %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	answers := map[string]interface{}{"zero": 0.0, "name": "Ada"}
+	huge := strings.Repeat("1000000000 * ", 35) + "1" // 1e315 overflows to +Inf
+	for _, src := range []string{
+		"1 / 0",
+		"5 % zero",
+		"1 / missing", // null is 0
+		"name * 2",
+		"name < 1",
+		"len(3)",
+		huge,
+	} {
+		e, err := ParseExpr(src)
+		if err != nil {
+			t.Errorf("%s: parse: %v", src, err)
+			continue
+		}
+		if v, err := e.Eval(answers); err == nil {
+			t.Errorf("%s = %#v, want an error", src, v)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct{ src, msg string }{
+		{"1 +", "unexpected"},
+		{"(1 + 2", "expected"},
+		{"'open", "unterminated string"},
+		{"{q1", "unterminated"},
+		{"{}", "empty"},
+		{"nosuch(1)", "unknown function"},
+		{"abs(1, 2)", "wrong number of arguments"},
+		{"1 2", "unexpected"},
+		{"a # b", "unexpected"},
+		{strings.Repeat("(", maxExprDepth+1) + "1" + strings.Repeat(")", maxExprDepth+1), "nested too deeply"},
+		{strings.Repeat("-", maxExprDepth+1) + "1", "nested too deeply"},
+		{strings.Repeat("1+", maxExprLength/2) + "1", "longer than"},
+	}
+	for _, tt := range tests {
+		_, err := ParseExpr(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("ParseExpr(%.30q) error = %v, want %q", tt.src, err, tt.msg)
+		}
+	}
+
+	ok := strings.Repeat("(", maxExprDepth-2) + "1" + strings.Repeat(")", maxExprDepth-2)
+	if _, err := ParseExpr(ok); err != nil {
+		t.Errorf("nesting within the limit: %v", err)
+	}
+}
+
+func TestExprRefs(t *testing.T) {
+	e, err := ParseExpr("a + round(b) + {3kx} + a + len('c')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Refs(), []string{"a", "b", "3kx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Refs = %v, want %v", got, want)
+	}
+}
+
+func TestRenameExprRefs(t *testing.T) {
+	ids := map[string]string{"a": "q-1", "b": "b2", "round": "nope", "3kx": "c"}
+	tests := []struct{ src, want string }{
+		{"a + b", "{q-1} + b2"},
+		{"round(a,  2)", "round({q-1},  2)"},
+		{"{3kx} * 'a'", "c * 'a'"},
+		{"x + 1", "x + 1"},
+	}
+	for _, tt := range tests {
+		got, err := RenameExprRefs(tt.src, ids)
+		if err != nil || got != tt.want {
+			t.Errorf("RenameExprRefs(%q) = %q, %v; want %q", tt.src, got, err, tt.want)
+			continue
+		}
+		// The result parses and reads the renamed fields; renaming back
+		// restores the original references.
+		e, err := ParseExpr(got)
+		if err != nil {
+			t.Errorf("%q does not parse: %v", got, err)
+			continue
+		}
+		back := map[string]string{}
+		for from, to := range ids {
+			back[to] = from
+		}
+		orig, _ := ParseExpr(tt.src)
+		again, err := RenameExprRefs(got, back)
+		if err != nil {
+			t.Errorf("renaming %q back: %v", got, err)
+			continue
+		}
+		e2, _ := ParseExpr(again)
+		if !reflect.DeepEqual(e2.Refs(), orig.Refs()) {
+			t.Errorf("round trip of %q: refs %v, want %v (via %v)", tt.src, e2.Refs(), orig.Refs(), e.Refs())
+		}
+	}
+	if _, err := RenameExprRefs("'open", ids); err == nil {
+		t.Error("RenameExprRefs accepted an unterminated string")
+	}
+}
@@ -0,0 +1,70 @@
+package logic
+
+import "sort"
+
+// Cycles returns the strongly connected components of the dependency graph
+// that contain a cycle (two or more nodes, or a node depending on itself).
+// deps maps each node to the nodes it reads; each cycle lists nodes in
+// sorted order.
+func Cycles(deps map[string][]string) [][]string {
+	nodes := make([]string, 0, len(deps))
+	for n := range deps {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes) // deterministic output
+
+	// Tarjan's algorithm.
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var out [][]string
+	next := 0
+
+	var strongConnect func(n string)
+	strongConnect = func(n string) {
+		index[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+
+		selfLoop := false
+		for _, m := range deps[n] {
+			if m == n {
+				selfLoop = true
+			}
+			if _, seen := index[m]; !seen {
+				strongConnect(m)
+				if low[m] < low[n] {
+					low[n] = low[m]
+				}
+			} else if onStack[m] && index[m] < low[n] {
+				low[n] = index[m]
+			}
+		}
+
+		if low[n] != index[n] {
+			return
+		}
+		var comp []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			comp = append(comp, m)
+			if m == n {
+				break
+			}
+		}
+		if len(comp) > 1 || selfLoop {
+			sort.Strings(comp)
+			out = append(out, comp)
+		}
+	}
+	for _, n := range nodes {
+		if _, seen := index[n]; !seen {
+			strongConnect(n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,249 @@
+package logic
+
+import (
+	"fmt"
+
+	"backend/fieldtypes"
+	"backend/models"
+)
+
+// Walk follows a respondent through the pages for answers and returns the
+// ids of the pages visited, in order, and which fields they were shown: a
+// field is shown when its page is on the path and its visibility condition
+// holds. Conditions only see answers to fields shown so far. Without pages
+// every field is on the path.
+func Walk(fields []models.Field, pages []models.Page, answers map[string]interface{}) ([]string, map[string]bool) {
+	if len(pages) == 0 {
+		return nil, Visible(fields, answers)
+	}
+	index := make(map[string]int, len(pages))
+	for i, p := range pages {
+		index[p.ID] = i
+	}
+	byID := make(map[string]models.Field, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+	}
+
+	var path []string
+	var reached []models.Field
+	shown := make(map[string]bool, len(fields))
+	given := map[string]interface{}{}
+	for i := 0; i >= 0 && i < len(pages); {
+		p := pages[i]
+		path = append(path, p.ID)
+		for _, id := range p.FieldIDs {
+			if f, ok := byID[id]; ok {
+				reached = append(reached, f)
+				if v, ok := answers[id]; ok {
+					given[id] = v
+				}
+			}
+		}
+		// A condition may read a field on a later page, so recompute over
+		// every field reached so far.
+		vis := Visible(reached, given)
+		view := make(map[string]interface{}, len(given))
+		for id, v := range given {
+			if vis[id] {
+				view[id] = v
+			}
+		}
+		for id, ok := range vis {
+			shown[id] = ok
+		}
+
+		target := p.Next
+		for _, b := range p.Branches {
+			if Evaluate(b.When, view) {
+				target = b.GoTo
+				break
+			}
+		}
+		switch {
+		case target == "":
+			i++
+		case target == models.PageEnd:
+			i = -1
+		default:
+			next, ok := index[target]
+			if !ok || next <= i { // rejected on save; never loop
+				next = i + 1
+			}
+			i = next
+		}
+	}
+	for _, f := range fields {
+		if _, ok := shown[f.ID]; !ok {
+			shown[f.ID] = false
+		}
+	}
+	return path, shown
+}
+
+// CheckPages validates a page layout against the form's fields. Paths are
+// rooted at the form ("pages[1].branches[0].goTo"). Every field must be on
+// exactly one page, branch targets must be later pages (or PageEnd), branch
+// conditions may only read fields answered by then, and every page must be
+// reachable from the first.
+func CheckPages(fields []models.Field, pages []models.Page) []fieldtypes.Problem {
+	if len(pages) == 0 {
+		return nil
+	}
+	var ps []fieldtypes.Problem
+	add := func(path, code, msg string) {
+		ps = append(ps, fieldtypes.Problem{Path: path, Code: code, Message: msg})
+	}
+
+	index := make(map[string]int, len(pages))
+	for i, p := range pages {
+		root := fmt.Sprintf("pages[%d]", i)
+		switch _, dup := index[p.ID]; {
+		case p.ID == "":
+			add(root+".id", fieldtypes.CodeRequired, "page must have an id")
+		case p.ID == models.PageEnd:
+			add(root+".id", fieldtypes.CodeInvalid, fmt.Sprintf("%q is reserved", models.PageEnd))
+		case dup:
+			add(root+".id", fieldtypes.CodeDuplicate, fmt.Sprintf("duplicate page id %q", p.ID))
+		default:
+			index[p.ID] = i
+		}
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.ID] = true
+	}
+	pageOf := make(map[string]int, len(fields))
+	for i, p := range pages {
+		for j, id := range p.FieldIDs {
+			path := fmt.Sprintf("pages[%d].fieldIds[%d]", i, j)
+			if !known[id] {
+				add(path, fieldtypes.CodeUnknownRef, fmt.Sprintf("unknown field %q", id))
+				continue
+			}
+			if first, dup := pageOf[id]; dup {
+				add(path, fieldtypes.CodeDuplicate, fmt.Sprintf("field %q is already on pages[%d]", id, first))
+				continue
+			}
+			pageOf[id] = i
+		}
+	}
+	for i, f := range fields {
+		if _, ok := pageOf[f.ID]; !ok && f.ID != "" {
+			add(fmt.Sprintf("fields[%d]", i), fieldtypes.CodeRequired, fmt.Sprintf("field %q is not on any page", f.ID))
+		}
+	}
+
+	// checkTarget validates a jump from page i and returns the page index it
+	// leads to, or -1.
+	checkTarget := func(path string, i int, target string) int {
+		if target == models.PageEnd {
+			return -1
+		}
+		to, ok := index[target]
+		switch {
+		case target == "":
+			add(path, fieldtypes.CodeRequired, "branch must have a target")
+		case !ok:
+			add(path, fieldtypes.CodeUnknownRef, fmt.Sprintf("unknown page %q", target))
+		case to <= i:
+			add(path, fieldtypes.CodeInvalid, "branches can only jump forward")
+		default:
+			return to
+		}
+		return -1
+	}
+
+	edges := make([][]int, len(pages))
+	for i, p := range pages {
+		root := fmt.Sprintf("pages[%d]", i)
+		for j, b := range p.Branches {
+			broot := fmt.Sprintf("%s.branches[%d]", root, j)
+			for _, pr := range CheckCondition(b.When, known) {
+				add(joinPath(broot+".when", pr.Path), pr.Code, pr.Message)
+			}
+			for _, ref := range Refs(&b.When) {
+				if at, ok := pageOf[ref]; ok && at > i {
+					add(broot+".when", fieldtypes.CodeInvalid, fmt.Sprintf("field %q is on a later page", ref))
+				}
+			}
+			if to := checkTarget(broot+".goTo", i, b.GoTo); to >= 0 {
+				edges[i] = append(edges[i], to)
+			}
+		}
+		switch {
+		case p.Next != "":
+			if to := checkTarget(root+".next", i, p.Next); to >= 0 {
+				edges[i] = append(edges[i], to)
+			}
+		case i+1 < len(pages):
+			edges[i] = append(edges[i], i+1)
+		}
+	}
+
+	reached := make([]bool, len(pages))
+	queue := []int{0}
+	reached[0] = true
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, to := range edges[i] {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	for i, ok := range reached {
+		if !ok {
+			add(fmt.Sprintf("pages[%d]", i), fieldtypes.CodeInvalid, fmt.Sprintf("page %q cannot be reached", pages[i].ID))
+		}
+	}
+	return ps
+}
+
+// AlwaysShown reports, per field, whether every respondent sees it: it has
+// no visibility condition and no branch can skip its page. Since branches
+// only jump forward, page k is skipped by some path exactly when an earlier
+// page can jump past it.
+func AlwaysShown(fields []models.Field, pages []models.Page) map[string]bool {
+	out := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		out[f.ID] = f.VisibleIf == nil
+	}
+	if len(pages) == 0 {
+		return out
+	}
+	index := make(map[string]int, len(pages))
+	for i, p := range pages {
+		index[p.ID] = i
+	}
+	// furthest is the furthest target of any jump from the pages before i;
+	// len(pages) stands for PageEnd.
+	furthest := 0
+	for i, p := range pages {
+		if furthest > i {
+			for _, id := range p.FieldIDs {
+				out[id] = false
+			}
+		}
+		targets := []string{p.Next}
+		for _, b := range p.Branches {
+			targets = append(targets, b.GoTo)
+		}
+		for _, t := range targets {
+			to, ok := index[t]
+			switch {
+			case t == models.PageEnd:
+				to = len(pages)
+			case !ok:
+				continue
+			}
+			if to > furthest {
+				furthest = to
+			}
+		}
+	}
+	return out
+}
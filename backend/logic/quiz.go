@@ -0,0 +1,66 @@
+package logic
+
+import (
+	"backend/fieldtypes"
+	"backend/models"
+)
+
+// Gradable reports whether a field is scored in quiz mode: it has correct
+// answers and its type implements fieldtypes.Grader.
+func Gradable(f models.Field) bool {
+	_, ok := grader(f)
+	return ok
+}
+
+func grader(f models.Field) (fieldtypes.Grader, bool) {
+	if len(f.CorrectAnswers) == 0 {
+		return nil, false
+	}
+	t, _ := fieldtypes.Lookup(f.Type)
+	g, ok := t.(fieldtypes.Grader)
+	return g, ok
+}
+
+// QuestionPoints is what a gradable field is worth; 1 unless set.
+func QuestionPoints(f models.Field) float64 {
+	if f.Points != nil {
+		return *f.Points
+	}
+	return 1
+}
+
+// Grade scores answers, as stored (normalized, hidden fields removed),
+// against form's correct answers. It returns nil for forms that are not
+// quizzes, and when the respondent was shown no question worth points, since
+// such a response can neither pass nor fail. Questions not in shown (see
+// Walk) are left out entirely. Each question earns its points times the
+// credit its type's GradeAnswer gives.
+func Grade(form models.Form, answers map[string]interface{}, shown map[string]bool) *models.Score {
+	if form.Quiz == nil {
+		return nil
+	}
+	score := &models.Score{Questions: []models.QuestionScore{}}
+	for _, f := range form.Fields {
+		g, ok := grader(f)
+		if !ok || !shown[f.ID] {
+			continue
+		}
+		q := models.QuestionScore{
+			FieldID:        f.ID,
+			MaxPoints:      QuestionPoints(f),
+			CorrectAnswers: append([]string(nil), f.CorrectAnswers...),
+		}
+		credit, correct := g.GradeAnswer(f, answers[f.ID])
+		q.Correct = correct
+		q.Points = q.MaxPoints * credit
+		score.Points += q.Points
+		score.MaxPoints += q.MaxPoints
+		score.Questions = append(score.Questions, q)
+	}
+	if score.MaxPoints == 0 {
+		return nil
+	}
+	score.Percent = score.Points / score.MaxPoints * 100
+	score.Passed = score.Percent >= form.Quiz.PassPercent
+	return score
+}
@@ -0,0 +1,57 @@
+package logic
+
+import "backend/models"
+
+// Visible decides which fields are shown for answers. A field whose
+// condition reads a hidden field sees that field as unanswered, so hiding
+// cascades. Conditions are assumed acyclic (see Cycles); a cycle that slips
+// through hides the fields on it.
+func Visible(fields []models.Field, answers map[string]interface{}) map[string]bool {
+	byID := make(map[string]models.Field, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+	}
+	const (
+		visiting = iota + 1
+		shown
+		hidden
+	)
+	state := make(map[string]int, len(fields))
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case shown:
+			return true
+		case hidden, visiting:
+			return false
+		}
+		f, ok := byID[id]
+		if !ok {
+			return false
+		}
+		if f.VisibleIf == nil {
+			state[id] = shown
+			return true
+		}
+		state[id] = visiting
+		view := make(map[string]interface{}, len(answers))
+		for _, ref := range Refs(f.VisibleIf) {
+			if v, ok := answers[ref]; ok && visit(ref) {
+				view[ref] = v
+			}
+		}
+		if Evaluate(*f.VisibleIf, view) {
+			state[id] = shown
+			return true
+		}
+		state[id] = hidden
+		return false
+	}
+
+	out := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		out[f.ID] = visit(f.ID)
+	}
+	return out
+}
@@ -13,6 +13,7 @@ import (
 
 	"backend/api"
 	"backend/db"
+	"backend/storage"
 )
 
 func main() {
@@ -27,8 +28,30 @@ func main() {
 		_ = db.Client().Disconnect(ctx)
 	}()
 
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+	local, err := storage.NewLocal(uploadDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	storage.Use(local)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := api.SweepUploads(context.Background()); err != nil {
+				log.Printf("upload sweep: %v", err)
+			}
+		}
+	}()
+
 	app := fiber.New(fiber.Config{
 		AppName: "Form Builder API",
+		// The server-wide cap fits one upload; api.Register holds every other
+		// route to the usual 4 MB. Streaming lets it reject a declared length
+		// before the body is read and bound a chunked body while reading it.
+		BodyLimit:         api.UploadBodyLimit,
+		StreamRequestBody: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -41,9 +64,10 @@ func main() {
 
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: os.Getenv("CORS_ORIGIN"), // change to your frontend origin in prod
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
+		AllowOrigins:  os.Getenv("CORS_ORIGIN"), // change to your frontend origin in prod
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match",
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		ExposeHeaders: "ETag",
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"ok": true}) })
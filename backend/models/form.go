@@ -2,7 +2,8 @@ package models
 
 import "time"
 
-// FieldType: "text" | "multipleChoice" | "checkboxes" | "rating"
+// Field is one question on a form. Type names an implementation registered
+// in package fieldtypes ("text", "email", "multipleChoice", "rating", ...).
 type Field struct {
 	ID          string   `bson:"id" json:"id"`
 	Label       string   `bson:"label" json:"label"`
@@ -16,14 +17,168 @@ type Field struct {
 	MaxChecked  *int     `bson:"maxChecked,omitempty" json:"maxChecked,omitempty"`
 	Scale       *int     `bson:"scale,omitempty" json:"scale,omitempty"` // rating
 	Min         *int     `bson:"min,omitempty" json:"min,omitempty"`     // rating min
+
+	VisibleIf *Condition `bson:"visibleIf,omitempty" json:"visibleIf,omitempty"` // shown only when true; see package logic
+
+	Expression string `bson:"expression,omitempty" json:"expression,omitempty"` // calculated; see logic.ParseExpr
+
+	// quiz grading (multipleChoice, checkboxes); used when Form.Quiz is set
+	CorrectAnswers []string `bson:"correctAnswers,omitempty" json:"correctAnswers,omitempty"`
+	Points         *float64 `bson:"points,omitempty" json:"points,omitempty"` // default 1
+	PartialCredit  bool     `bson:"partialCredit,omitempty" json:"partialCredit,omitempty"`
+
+	DefaultCountryCode *string `bson:"defaultCountryCode,omitempty" json:"defaultCountryCode,omitempty"` // phone, e.g. "+44"
+
+	// number
+	MinValue    *float64 `bson:"minValue,omitempty" json:"minValue,omitempty"`
+	MaxValue    *float64 `bson:"maxValue,omitempty" json:"maxValue,omitempty"`
+	Step        *float64 `bson:"step,omitempty" json:"step,omitempty"`
+	IntegerOnly bool     `bson:"integerOnly,omitempty" json:"integerOnly,omitempty"`
+	Unit        *string  `bson:"unit,omitempty" json:"unit,omitempty"`
+
+	// date, time, datetime: bounds use the answer format ("2006-01-02",
+	// "15:04", RFC 3339); Timezone is an IANA name, UTC if unset.
+	Earliest     *string `bson:"earliest,omitempty" json:"earliest,omitempty"`
+	Latest       *string `bson:"latest,omitempty" json:"latest,omitempty"`
+	DisallowPast bool    `bson:"disallowPast,omitempty" json:"disallowPast,omitempty"`
+	Timezone     *string `bson:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// dropdown
+	Choices     []Choice `bson:"choices,omitempty" json:"choices,omitempty"`
+	Searchable  bool     `bson:"searchable,omitempty" json:"searchable,omitempty"`
+	AllowCustom bool     `bson:"allowCustom,omitempty" json:"allowCustom,omitempty"`
+	TopN        *int     `bson:"topN,omitempty" json:"topN,omitempty"` // bars shown in analytics before "Others"
+
+	// matrix: answers map row id -> column (or columns when MultiSelect)
+	Rows        []MatrixRow `bson:"rows,omitempty" json:"rows,omitempty"`
+	Columns     []string    `bson:"columns,omitempty" json:"columns,omitempty"`
+	MultiSelect bool        `bson:"multiSelect,omitempty" json:"multiSelect,omitempty"`
+
+	RankTop *int `bson:"rankTop,omitempty" json:"rankTop,omitempty"` // ranking: rank only the top K of Options
+
+	// file: AllowedTypes are MIME types, "image/*" style wildcards allowed;
+	// an empty list accepts any type.
+	AllowedTypes []string `bson:"allowedTypes,omitempty" json:"allowedTypes,omitempty"`
+	MaxFileSize  *int64   `bson:"maxFileSize,omitempty" json:"maxFileSize,omitempty"` // bytes
+	MaxFiles     *int     `bson:"maxFiles,omitempty" json:"maxFiles,omitempty"`
+}
+
+// Choice is a dropdown option: Value is what answers store, Label is shown.
+type Choice struct {
+	Value string `bson:"value" json:"value"`
+	Label string `bson:"label,omitempty" json:"label,omitempty"`
+}
+
+// MatrixRow is one statement in a matrix grid. A required field requires
+// every row; otherwise only rows marked Required must be answered.
+type MatrixRow struct {
+	ID       string `bson:"id" json:"id"`
+	Label    string `bson:"label" json:"label"`
+	Required bool   `bson:"required,omitempty" json:"required,omitempty"`
+}
+
+// Condition tests other fields' answers. A leaf compares the answer to Field
+// using Op ("equals", "contains", "greaterThan") against Value; a group
+// holds All (every one true) or Any (at least one true) instead.
+type Condition struct {
+	Field string      `bson:"field,omitempty" json:"field,omitempty"`
+	Op    string      `bson:"op,omitempty" json:"op,omitempty"`
+	Value interface{} `bson:"value,omitempty" json:"value,omitempty"`
+	All   []Condition `bson:"all,omitempty" json:"all,omitempty"`
+	Any   []Condition `bson:"any,omitempty" json:"any,omitempty"`
 }
 
+// Page groups fields into one screen of a multi-page form. After a page the
+// respondent goes to the first Branches target whose condition holds, else
+// to Next, else to the following page. Targets are page ids or PageEnd.
+type Page struct {
+	ID          string   `bson:"id" json:"id"`
+	Title       string   `bson:"title,omitempty" json:"title,omitempty"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	FieldIDs    []string `bson:"fieldIds" json:"fieldIds"`
+	Branches    []Branch `bson:"branches,omitempty" json:"branches,omitempty"`
+	Next        string   `bson:"next,omitempty" json:"next,omitempty"`
+}
+
+// Branch jumps to GoTo when When holds for the answers given so far.
+type Branch struct {
+	When Condition `bson:"when" json:"when"`
+	GoTo string    `bson:"goTo" json:"goTo"`
+}
+
+// PageEnd as a branch target skips the remaining pages and submits.
+const PageEnd = "end"
+
+// Quiz turns on grading: fields with CorrectAnswers are scored and each
+// response records its Score. PassPercent is the share of available points
+// needed to pass, 0-100.
+type Quiz struct {
+	PassPercent float64 `bson:"passPercent" json:"passPercent"`
+}
+
+// Form lifecycle: draft -> published -> closed (published <-> draft via unpublish)
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusClosed    = "closed"
+)
+
 type Form struct {
 	ID             string     `bson:"_id" json:"id"`
 	Title          string     `bson:"title" json:"title"`
 	Fields         []Field    `bson:"fields" json:"fields"`
+	Pages          []Page     `bson:"pages,omitempty" json:"pages,omitempty"` // empty: one page with every field
+	Quiz           *Quiz      `bson:"quiz,omitempty" json:"quiz,omitempty"`   // set: responses are graded
+	Status         string     `bson:"status,omitempty" json:"status"`
+	Availability   string     `bson:"-" json:"availability,omitempty"`  // AvailabilityAt(now), filled in when served
+	Version        int        `bson:"version,omitempty" json:"version"` // latest published FormVersion, 0 if never published
+	Revision       int64      `bson:"revision" json:"revision"`         // bumped on every definition edit; served as the ETag
+	PublishedAt    *time.Time `bson:"publishedAt,omitempty" json:"publishedAt,omitempty"`
+	ClosedAt       *time.Time `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	ArchivedAt     *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"` // soft-deleted
 	CreatedAt      time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time  `bson:"updatedAt" json:"updatedAt"`
 	ResponseCount  int64      `bson:"responseCount" json:"responseCount"`
 	LastResponseAt *time.Time `bson:"lastResponseAt,omitempty" json:"lastResponseAt,omitempty"`
+
+	// Schedule: a published form only accepts responses from OpensAt until
+	// ClosesAt, and only until ResponseCount reaches MaxResponses.
+	OpensAt      *time.Time `bson:"opensAt,omitempty" json:"opensAt,omitempty"`
+	ClosesAt     *time.Time `bson:"closesAt,omitempty" json:"closesAt,omitempty"`
+	MaxResponses *int64     `bson:"maxResponses,omitempty" json:"maxResponses,omitempty"`
+	ReservedAt   *time.Time `bson:"reservedAt,omitempty" json:"-"` // last slot reserved against ResponseCount
+}
+
+// Availability of a published form: whether its schedule and response cap
+// let it take a response right now.
+const (
+	AvailabilityOpen       = "open"
+	AvailabilityNotYetOpen = "notYetOpen" // before OpensAt
+	AvailabilityEnded      = "ended"      // at or after ClosesAt
+	AvailabilityFull       = "full"       // ResponseCount reached MaxResponses
+)
+
+// AvailabilityAt reports the availability of the form at now, or "" when it
+// is not published (the status says why it takes no responses).
+func (f Form) AvailabilityAt(now time.Time) string {
+	switch {
+	case f.ArchivedAt != nil || f.EffectiveStatus() != StatusPublished:
+		return ""
+	case f.OpensAt != nil && now.Before(*f.OpensAt):
+		return AvailabilityNotYetOpen
+	case f.ClosesAt != nil && !now.Before(*f.ClosesAt):
+		return AvailabilityEnded
+	case f.MaxResponses != nil && f.ResponseCount >= *f.MaxResponses:
+		return AvailabilityFull
+	}
+	return AvailabilityOpen
+}
+
+// EffectiveStatus treats forms saved before the lifecycle existed (no status)
+// as published, since they were already accepting responses.
+func (f Form) EffectiveStatus() string {
+	if f.Status == "" {
+		return StatusPublished
+	}
+	return f.Status
 }
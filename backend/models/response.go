@@ -5,6 +5,29 @@ import "time"
 type Response struct {
 	ID          string                 `bson:"_id" json:"id"`
 	FormID      string                 `bson:"formId" json:"formId"`
+	Version     int                    `bson:"version,omitempty" json:"version,omitempty"`
 	SubmittedAt time.Time              `bson:"submittedAt" json:"submittedAt"`
 	Answers     map[string]interface{} `bson:"answers" json:"answers"`
+	Score       *Score                 `bson:"score,omitempty" json:"score,omitempty"` // quiz forms only
+}
+
+// Score is a graded response. Only questions the respondent was shown count
+// towards MaxPoints; a response shown nothing worth points is not graded.
+type Score struct {
+	Points    float64         `bson:"points" json:"points"`
+	MaxPoints float64         `bson:"maxPoints" json:"maxPoints"`
+	Percent   float64         `bson:"percent" json:"percent"`
+	Passed    bool            `bson:"passed" json:"passed"`
+	Questions []QuestionScore `bson:"questions" json:"questions"`
+}
+
+// QuestionScore is one graded question. CorrectAnswers is the answer key it
+// was graded against, kept so later edits to the key do not change what the
+// score means.
+type QuestionScore struct {
+	FieldID        string   `bson:"fieldId" json:"fieldId"`
+	Points         float64  `bson:"points" json:"points"`
+	MaxPoints      float64  `bson:"maxPoints" json:"maxPoints"`
+	Correct        bool     `bson:"correct" json:"correct"` // full credit
+	CorrectAnswers []string `bson:"correctAnswers" json:"correctAnswers"`
 }
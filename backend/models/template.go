@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Template is a reusable form definition that new forms can be created from.
+type Template struct {
+	ID          string    `bson:"_id" json:"id"`
+	Name        string    `bson:"name" json:"name"`
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	Title       string    `bson:"title" json:"title"`
+	Fields      []Field   `bson:"fields" json:"fields"`
+	Pages       []Page    `bson:"pages,omitempty" json:"pages,omitempty"`
+	Quiz        *Quiz     `bson:"quiz,omitempty" json:"quiz,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt" json:"createdAt"`
+}
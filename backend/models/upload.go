@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Upload is a file attached to a file field. Its contents live in the
+// storage backend under the same id; answers to the field list upload ids.
+type Upload struct {
+	ID          string    `bson:"_id" json:"id"`
+	FormID      string    `bson:"formId" json:"formId"`
+	FieldID     string    `bson:"fieldId" json:"fieldId"`
+	ResponseID  string    `bson:"responseId,omitempty" json:"responseId,omitempty"` // set once a submitted response claims it
+	Name        string    `bson:"name" json:"name"`
+	ContentType string    `bson:"contentType" json:"contentType"`
+	Size        int64     `bson:"size" json:"size"`
+	CreatedAt   time.Time `bson:"createdAt" json:"createdAt"`
+}
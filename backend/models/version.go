@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// FormVersion is an immutable snapshot of a form's definition, written each
+// time the form is published. Responses record the version they answered.
+type FormVersion struct {
+	ID          string    `bson:"_id" json:"id"`
+	FormID      string    `bson:"formId" json:"formId"`
+	Version     int       `bson:"version" json:"version"`
+	Title       string    `bson:"title" json:"title"`
+	Fields      []Field   `bson:"fields" json:"fields"`
+	Pages       []Page    `bson:"pages,omitempty" json:"pages,omitempty"`
+	Quiz        *Quiz     `bson:"quiz,omitempty" json:"quiz,omitempty"`
+	PublishedAt time.Time `bson:"publishedAt" json:"publishedAt"`
+}
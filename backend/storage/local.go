@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Local stores each object as a file named after its id in one directory.
+type Local struct {
+	dir string
+}
+
+var objectID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// NewLocal creates dir if needed and returns a backend rooted there.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+func (l *Local) path(id string) (string, error) {
+	if !objectID.MatchString(id) {
+		return "", fmt.Errorf("storage: invalid object id %q", id)
+	}
+	return filepath.Join(l.dir, id), nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers never
+// see a partial object.
+func (l *Local) Put(ctx context.Context, id string, r io.Reader) error {
+	p, err := l.path(id)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	p, err := l.path(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, id string) error {
+	p, err := l.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
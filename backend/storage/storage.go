@@ -0,0 +1,29 @@
+// Package storage keeps uploaded file contents. Metadata (name, type, owner)
+// lives in Mongo; a Backend only maps object ids to bytes, so an
+// S3-compatible store can be dropped in next to the local one.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Open and Delete for unknown ids.
+var ErrNotFound = errors.New("storage: object not found")
+
+type Backend interface {
+	// Put stores the contents of r under id, replacing any existing object.
+	Put(ctx context.Context, id string, r io.Reader) error
+	// Open returns the contents stored under id.
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	// Delete removes the object; deleting a missing object is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+var current Backend
+
+// Use sets the backend returned by Default. Call it once at startup.
+func Use(b Backend) { current = b }
+
+func Default() Backend { return current }
@@ -10,7 +10,7 @@ import FormBuilderCanvas from '@/components/builder/FormBuilderCanvas';
 import Palette from '@/components/builder/Palette';
 import FieldInput from '@/components/fields/FieldInput';
 import useDraftAutosave from '@/hooks/useDraftAutosave';
-import { getForm } from '@/lib/api';
+import { getForm, publishForm } from '@/lib/api';
 
 function extractFormId(input: string): string | null {
 	if (!input) return null;
@@ -42,7 +42,15 @@ export default function BuilderPage() {
 
 	const { formId, lastSavedAt, saveNow, setFormId } = useDraftAutosave(
 		{ id: null, title, fields },
-		{ delay: 600 },
+		{
+			delay: 600,
+			onConflict: (current) => {
+				// someone else saved first: show their copy rather than overwrite it
+				setTitle(current.title);
+				setFields(current.fields as AnyField[]);
+				alert('This form was changed elsewhere. Loaded the latest version.');
+			},
+		},
 	);
 
 	const errors = useMemo(
@@ -64,8 +72,14 @@ export default function BuilderPage() {
 	};
 
 	const onPublish = async () => {
-		await saveNow(); // ensures latest autosave persisted
-		// shareUrl already reflects the id
+		const id = await saveNow(); // ensures latest autosave persisted
+		if (!id) return;
+		try {
+			await publishForm(id); // drafts do not accept responses
+		} catch (e) {
+			console.error(e);
+			alert('Could not publish form.');
+		}
 	};
 
 	const onLoadDraft = async () => {
@@ -80,7 +94,7 @@ export default function BuilderPage() {
 			const f = await getForm(id);
 			setTitle(f.title);
 			setFields(f.fields as AnyField[]);
-			setFormId(f.id); // future autosaves will PUT this form
+			setFormId(f); // future autosaves will PUT this form
 			setMode('build');
 		} catch (e) {
 			console.error(e);
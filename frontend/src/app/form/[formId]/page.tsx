@@ -6,7 +6,7 @@ import { useEffect, useMemo, useState } from 'react';
 import { AnyField, FormAnswers, FormDoc } from '@/lib/types';
 import { validateAnswers } from '@/lib/validation';
 import FieldInput from '@/components/fields/FieldInput';
-import { getForm, submitResponse } from '@/lib/api';
+import { getRespondentForm, submitResponse } from '@/lib/api';
 
 export default function FillFormPage() {
 	const { formId } = useParams<{ formId: string }>();
@@ -19,7 +19,7 @@ export default function FillFormPage() {
 		let alive = true;
 		(async () => {
 			try {
-				const f = await getForm(formId);
+				const f = await getRespondentForm(formId);
 				if (alive) setForm(f);
 			} catch {
 				if (alive) setForm(null);
@@ -38,6 +38,15 @@ export default function FillFormPage() {
 	if (!form)
 		return <div className='p-6 max-w-3xl mx-auto'>Form not found.</div>;
 
+	const unavailable = unavailableMessage(form);
+	if (unavailable)
+		return (
+			<div className='p-6 max-w-3xl mx-auto'>
+				<h1 className='text-xl font-semibold mb-4 text-app'>{form.title}</h1>
+				<p className='text-muted'>{unavailable}</p>
+			</div>
+		);
+
 	const onSubmit = async () => {
 		const errs = validateAnswers(form.fields as AnyField[], answers);
 		if (Object.keys(errs).length) return;
@@ -91,3 +100,21 @@ export default function FillFormPage() {
 		</div>
 	);
 }
+
+// unavailableMessage explains why the form takes no responses right now, or
+// returns null when it does.
+function unavailableMessage(form: FormDoc): string | null {
+	if (form.status === 'draft') return 'This form is not published yet.';
+	if (form.status === 'closed') return 'This form is closed.';
+	switch (form.availability) {
+		case 'notYetOpen':
+			return form.opensAt
+				? `This form opens on ${new Date(form.opensAt).toLocaleString()}.`
+				: 'This form is not open yet.';
+		case 'ended':
+			return 'This form is closed.';
+		case 'full':
+			return 'This form has reached its response limit.';
+	}
+	return null;
+}
@@ -3,7 +3,7 @@
 
 import { useCallback, useEffect, useRef, useState } from 'react';
 import type { AnyField, FormDoc } from '@/lib/types';
-import { createForm, updateForm } from '@/lib/api';
+import { ConflictError, createForm, updateForm } from '@/lib/api';
 
 type Draft = {
 	id: string | null;
@@ -13,13 +13,16 @@ type Draft = {
 
 type Options = {
 	delay?: number; // ms
+	// Called when someone else saved the form first; `current` is their copy,
+	// which later autosaves are based on.
+	onConflict?: (current: FormDoc) => void;
 };
 
 type UseDraftAutosave = {
 	formId: string | null;
 	lastSavedAt: number | null;
-	saveNow: () => Promise<void>;
-	setFormId: (id: string | null) => void;
+	saveNow: () => Promise<string | null>;
+	setFormId: (form: Pick<FormDoc, 'id' | 'revision'> | null) => void;
 };
 
 /**
@@ -36,29 +39,41 @@ export default function useDraftAutosave(
 	const [lastSavedAt, setLastSavedAt] = useState<number | null>(null);
 
 	const timer = useRef<ReturnType<typeof setTimeout> | null>(null);
-	const inflight = useRef<Promise<void> | null>(null);
+	const inflight = useRef<Promise<string | null> | null>(null);
+	const revision = useRef<number>(0);
 	const latest = useRef<Draft>(draft);
 	latest.current = draft;
+	const onConflict = useRef(opts.onConflict);
+	onConflict.current = opts.onConflict;
 
-	const doSave = useCallback(async () => {
+	const doSave = useCallback(async (): Promise<string | null> => {
 		const { title, fields } = latest.current;
-		if (!title && fields.length === 0) return;
+		if (!title && fields.length === 0) return formId;
 
 		let saved: FormDoc;
 		if (!formId) {
 			saved = await createForm({ title, fields });
 			setFormId(saved.id);
 		} else {
-			saved = await updateForm(formId, { title, fields });
+			try {
+				saved = await updateForm(formId, { title, fields }, revision.current);
+			} catch (e) {
+				if (!(e instanceof ConflictError)) throw e;
+				revision.current = e.current.revision ?? 0;
+				onConflict.current?.(e.current);
+				return null;
+			}
 		}
+		revision.current = saved.revision ?? 0;
 		setLastSavedAt(Date.now());
+		return saved.id;
 	}, [formId]);
 
 	const saveNow = useCallback(async () => {
 		if (inflight.current) await inflight.current;
 		inflight.current = doSave();
 		try {
-			await inflight.current;
+			return await inflight.current;
 		} finally {
 			inflight.current = null;
 		}
@@ -75,5 +90,13 @@ export default function useDraftAutosave(
 		};
 	}, [draft.title, draft.fields, delay, saveNow]);
 
-	return { formId, lastSavedAt, saveNow, setFormId };
+	const selectForm = useCallback(
+		(form: Pick<FormDoc, 'id' | 'revision'> | null) => {
+			revision.current = form?.revision ?? 0;
+			setFormId(form?.id ?? null);
+		},
+		[],
+	);
+
+	return { formId, lastSavedAt, saveNow, setFormId: selectForm };
 }
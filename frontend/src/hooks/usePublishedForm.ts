@@ -3,7 +3,7 @@
 
 import { useEffect, useState } from 'react';
 import { FormDoc } from '@/lib/types';
-import { getForm } from '@/lib/api';
+import { getRespondentForm } from '@/lib/api';
 
 export default function usePublishedForm(formId: string | null) {
 	const [form, setForm] = useState<FormDoc | null>(null);
@@ -21,7 +21,7 @@ export default function usePublishedForm(formId: string | null) {
 			}
 			setLoading(true);
 			try {
-				const f = await getForm(formId);
+				const f = await getRespondentForm(formId);
 				if (!alive) return;
 				setForm(f);
 				setNotFound(false);
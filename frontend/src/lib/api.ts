@@ -17,9 +17,26 @@ const API_BASE =
 type ApiError = {
 	error?: string;
 	message?: string;
-	errors?: Record<string, string>;
+	// answer errors keyed by field id, or form definition errors with paths
+	errors?:
+		| Record<string, string>
+		| { path: string; fieldId?: string; code: string; message: string }[];
+	// the server copy on a 409
+	current?: FormDoc;
 };
 
+// Thrown when a write was based on a stale revision; `current` is the form as
+// the server has it now.
+export class ConflictError extends Error {
+	constructor(
+		message: string,
+		public current: FormDoc,
+	) {
+		super(message);
+		this.name = 'ConflictError';
+	}
+}
+
 async function jsonOrThrow<T>(res: Response): Promise<T> {
 	const contentType = res.headers.get('content-type') ?? '';
 	const parse = async (): Promise<unknown> =>
@@ -28,6 +45,12 @@ async function jsonOrThrow<T>(res: Response): Promise<T> {
 	if (!res.ok) {
 		const body = (await parse()) as unknown;
 		const err = body as ApiError | string;
+		if (res.status === 409 && typeof err === 'object' && err?.current) {
+			throw new ConflictError(
+				`HTTP 409: ${err.error ?? 'conflict'}`,
+				err.current,
+			);
+		}
 		let msg = `HTTP ${res.status}`;
 		if (typeof err === 'string' && err.trim()) msg = `${msg}: ${err}`;
 		else if (typeof err === 'object' && err) {
@@ -54,13 +77,19 @@ export async function createForm(payload: {
 	return jsonOrThrow<FormDoc>(res);
 }
 
+// `revision` is the FormDoc revision the edit is based on; the server rejects
+// the write with 409 (a ConflictError) if someone else saved in the meantime.
 export async function updateForm(
 	id: string,
 	payload: Partial<Pick<FormDoc, 'title' | 'fields'>>,
+	revision: number,
 ): Promise<FormDoc> {
 	const res = await fetch(`${API_BASE}/api/forms/${id}`, {
 		method: 'PUT',
-		headers: { 'Content-Type': 'application/json' },
+		headers: {
+			'Content-Type': 'application/json',
+			'If-Match': `"${revision}"`,
+		},
 		body: JSON.stringify(payload),
 	});
 	return jsonOrThrow<FormDoc>(res);
@@ -71,10 +100,32 @@ export async function getForm(id: string): Promise<FormDoc> {
 	return jsonOrThrow<FormDoc>(res);
 }
 
-// Optional publish endpoint (safe no-op if you didn’t add it on backend)
+// The form as shown to respondents: quiz answer keys and points are omitted.
+export async function getRespondentForm(id: string): Promise<FormDoc> {
+	const res = await fetch(`${API_BASE}/api/forms/${id}/respond`, {
+		cache: 'no-store',
+	});
+	return jsonOrThrow<FormDoc>(res);
+}
+
+// Lifecycle: draft -> published -> closed
 export async function publishForm(id: string): Promise<FormDoc> {
 	const res = await fetch(`${API_BASE}/api/forms/${id}/publish`, {
-		method: 'PUT',
+		method: 'POST',
+	});
+	return jsonOrThrow<FormDoc>(res);
+}
+
+export async function unpublishForm(id: string): Promise<FormDoc> {
+	const res = await fetch(`${API_BASE}/api/forms/${id}/unpublish`, {
+		method: 'POST',
+	});
+	return jsonOrThrow<FormDoc>(res);
+}
+
+export async function closeForm(id: string): Promise<FormDoc> {
+	const res = await fetch(`${API_BASE}/api/forms/${id}/close`, {
+		method: 'POST',
 	});
 	return jsonOrThrow<FormDoc>(res);
 }
@@ -67,10 +67,22 @@ export interface FormAnswers {
  * Form / Response documents (as returned by backend)
  * -------------------------------------------------------------------------- */
 
+export type FormStatus = 'draft' | 'published' | 'closed';
+
+// Whether a published form takes responses right now (schedule and cap).
+export type FormAvailability = 'open' | 'notYetOpen' | 'ended' | 'full';
+
 export interface FormDoc {
 	id: string;
 	title: string;
 	fields: AnyField[];
+	status?: FormStatus;
+	availability?: FormAvailability;
+	opensAt?: string;
+	closesAt?: string;
+	maxResponses?: number;
+	version?: number;
+	revision?: number;
 
 	// Your previous shape
 	createdAt: number;